@@ -25,11 +25,12 @@ import (
 	heartbeatcmd "github.com/gardener/gardener/extensions/pkg/controller/heartbeat/cmd"
 	osccontroller "github.com/gardener/gardener/extensions/pkg/controller/operatingsystemconfig"
 	"github.com/gardener/gardener/extensions/pkg/util"
+	webhookcmd "github.com/gardener/gardener/extensions/pkg/webhook/cmd"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
 	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	oscwebhook "github.com/metal-stack/os-metal-extension/pkg/webhook/operatingsystemconfig"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
-	componentbaseconfig "k8s.io/component-base/config"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -46,6 +47,8 @@ func NewControllerCommand(ctx context.Context) *cobra.Command {
 			LeaderElection:          true,
 			LeaderElectionID:        controllercmd.LeaderElectionNameID(ctrlName),
 			LeaderElectionNamespace: os.Getenv("LEADER_ELECTION_NAMESPACE"),
+			WebhookServerPort:       10250,
+			WebhookCertDir:          "/tmp/gardener-extensions-cert",
 		}
 		ctrlOpts = &controllercmd.ControllerOptions{
 			MaxConcurrentReconciles: 5,
@@ -59,11 +62,24 @@ func NewControllerCommand(ctx context.Context) *cobra.Command {
 
 		reconcileOpts = &controllercmd.ReconcilerOptions{}
 
+		configOpts   = &ConfigOptions{}
+		actuatorOpts = &ActuatorOptions{}
+
 		controllerSwitches = controllercmd.NewSwitchOptions(
 			controllercmd.Switch(osccontroller.ControllerName, operatingsystemconfig.AddToManager),
 			controllercmd.Switch(heartbeat.ControllerName, heartbeat.AddToManager),
 		)
 
+		// options for the webhook server
+		webhookServerOptions = &webhookcmd.ServerOptions{
+			Namespace: os.Getenv("WEBHOOK_CONFIG_NAMESPACE"),
+		}
+
+		webhookSwitches = webhookcmd.NewSwitchOptions(
+			webhookcmd.Switch(oscwebhook.WebhookName, oscwebhook.AddToManager),
+		)
+		webhookOptions = webhookcmd.NewAddToManagerOptions(ctrlName, "", nil, webhookServerOptions, webhookSwitches)
+
 		aggOption = controllercmd.NewOptionAggregator(
 			generalOpts,
 			restOpts,
@@ -71,7 +87,9 @@ func NewControllerCommand(ctx context.Context) *cobra.Command {
 			ctrlOpts,
 			controllercmd.PrefixOption("heartbeat-", heartbeatCtrlOpts),
 			reconcileOpts,
+			actuatorOpts,
 			controllerSwitches,
+			webhookOptions,
 		)
 	)
 
@@ -79,17 +97,26 @@ func NewControllerCommand(ctx context.Context) *cobra.Command {
 		Use: ctrlName + "-controller-manager",
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// the configuration file is loaded first, such that its values are completed like the flags they provide defaults for
+			if err := configOpts.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+			cfg := configOpts.Completed()
+			if err := configOpts.ApplyFeatureGates(cmd.Flags()); err != nil {
+				return err
+			}
+			if cfg.ConcurrentSyncs != nil && !cmd.Flags().Changed(controllercmd.MaxConcurrentReconcilesFlag) {
+				ctrlOpts.MaxConcurrentReconciles = *cfg.ConcurrentSyncs
+			}
+			actuatorOpts.ApplyControllerConfiguration(cfg, cmd.Flags())
+
 			if err := aggOption.Complete(); err != nil {
 				return fmt.Errorf("error completing options: %w", err)
 			}
 			if err := heartbeatCtrlOpts.Validate(); err != nil {
 				return err
 			}
-			// TODO: Make these flags configurable via command line parameters or component config file.
-			util.ApplyClientConnectionConfigurationToRESTConfig(&componentbaseconfig.ClientConnectionConfiguration{
-				QPS:   100.0,
-				Burst: 130,
-			}, restOpts.Completed().Config)
+			util.ApplyClientConnectionConfigurationToRESTConfig(cfg.ClientConnection, restOpts.Completed().Config)
 
 			completedMgrOpts := mgrOpts.Completed().Options()
 			completedMgrOpts.Client = client.Options{
@@ -111,9 +138,21 @@ func NewControllerCommand(ctx context.Context) *cobra.Command {
 
 			ctrlOpts.Completed().Apply(&operatingsystemconfig.DefaultAddOptions.Controller)
 			heartbeatCtrlOpts.Completed().Apply(&heartbeat.DefaultAddOptions)
+			actuatorOpts.Completed().Apply(&operatingsystemconfig.DefaultAddOptions.Actuator)
 
 			reconcileOpts.Completed().Apply(&operatingsystemconfig.DefaultAddOptions.IgnoreOperationAnnotation, ptr.To(extensionsv1alpha1.ExtensionClassShoot))
 
+			// the webhook is optional, its server and certificates are only set up if it is enabled
+			if !webhookSwitches.Completed().Disabled {
+				if err := mgr.AddReadyzCheck("webhook-server", mgr.GetWebhookServer().StartedChecker()); err != nil {
+					return fmt.Errorf("could not add readycheck of webhook to manager: %w", err)
+				}
+
+				if _, err := webhookOptions.Completed().AddToManager(ctx, mgr, nil); err != nil {
+					return fmt.Errorf("could not add webhooks to manager: %w", err)
+				}
+			}
+
 			if err := controllerSwitches.Completed().AddToManager(ctx, mgr); err != nil {
 				return fmt.Errorf("could not add controller to manager: %w", err)
 			}
@@ -126,7 +165,10 @@ func NewControllerCommand(ctx context.Context) *cobra.Command {
 		},
 	}
 
+	configOpts.AddFlags(cmd.Flags())
 	aggOption.AddFlags(cmd.Flags())
 
+	cmd.AddCommand(NewRenderCommand(ctx))
+
 	return cmd
 }
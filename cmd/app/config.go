@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/metal-stack/os-metal-extension/pkg/apis/config"
+	configloader "github.com/metal-stack/os-metal-extension/pkg/apis/config/loader"
+	configvalidation "github.com/metal-stack/os-metal-extension/pkg/apis/config/validation"
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig/ignition"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// ConfigFlag is the name of the command line flag to specify the controller configuration file.
+	ConfigFlag = "config"
+	// FeatureGatesFlag is the name of the command line flag to set the feature gates of the extension.
+	FeatureGatesFlag = "feature-gates"
+)
+
+// ConfigOptions are command line options to load the config.ControllerConfiguration from a file.
+type ConfigOptions struct {
+	// ConfigFile is the path to the controller configuration file.
+	ConfigFile string
+
+	config *config.ControllerConfiguration
+}
+
+// AddFlags implements Flagger.AddFlags.
+func (c *ConfigOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&c.ConfigFile, ConfigFlag, "", "Path to a ControllerConfiguration file. Command line flags which are set explicitly take precedence over the values of the file.")
+	features.DefaultFeatureGate.AddFlag(fs)
+}
+
+// Complete implements Completer.Complete. Without a configuration file the defaulted configuration is used.
+func (c *ConfigOptions) Complete() error {
+	var (
+		cfg *config.ControllerConfiguration
+		err error
+	)
+
+	if c.ConfigFile != "" {
+		cfg, err = configloader.LoadFromFile(c.ConfigFile)
+	} else {
+		cfg, err = configloader.Load(nil)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to load --%s: %w", ConfigFlag, err)
+	}
+
+	if errs := configvalidation.ValidateControllerConfiguration(cfg); len(errs) > 0 {
+		return fmt.Errorf("invalid --%s: %w", ConfigFlag, errs.ToAggregate())
+	}
+
+	c.config = cfg
+	return nil
+}
+
+// Completed returns the completed ControllerConfiguration. Only call this if `Complete` was successful.
+func (c *ConfigOptions) Completed() *config.ControllerConfiguration {
+	return c.config
+}
+
+// ApplyFeatureGates sets the feature gates of the completed configuration, unless --feature-gates is set explicitly,
+// which replaces the feature gates of the configuration as a whole. Only call this if `Complete` was successful.
+func (c *ConfigOptions) ApplyFeatureGates(fs *pflag.FlagSet) error {
+	if len(c.config.FeatureGates) == 0 || fs.Changed(FeatureGatesFlag) {
+		return nil
+	}
+
+	if err := features.DefaultFeatureGate.SetFromMap(c.config.FeatureGates); err != nil {
+		return fmt.Errorf("unable to set feature gates of --%s: %w", ConfigFlag, err)
+	}
+
+	return nil
+}
+
+// ApplyControllerConfiguration sets the values of the given configuration for all flags which are not set explicitly.
+// It must be called before `Complete`, such that the values are validated in the same way as the flags.
+func (a *ActuatorOptions) ApplyControllerConfiguration(cfg *config.ControllerConfiguration, fs *pflag.FlagSet) {
+	if cfg.Ignition.Version != nil && !fs.Changed(IgnitionVersionFlag) {
+		a.IgnitionVersion = *cfg.Ignition.Version
+	}
+	if cfg.Ignition.CompressionThreshold != nil && !fs.Changed(IgnitionCompressionThresholdFlag) {
+		a.IgnitionCompressionThreshold = *cfg.Ignition.CompressionThreshold
+	}
+	if cfg.Ignition.Strict != nil && !fs.Changed(IgnitionStrictFlag) {
+		a.IgnitionStrict = *cfg.Ignition.Strict
+	}
+	if cfg.MaxUserDataSize != nil && !fs.Changed(MaxUserDataSizeFlag) {
+		a.MaxUserDataSize = *cfg.MaxUserDataSize
+	}
+	if cfg.AllowedFilePaths != nil && !fs.Changed(AllowedFilePathsFlag) {
+		a.AllowedFilePaths = cfg.AllowedFilePaths
+	}
+	if cfg.LenientProviderConfigDecoding != nil && !fs.Changed(LenientProviderConfigDecodingFlag) {
+		a.LenientProviderConfigDecoding = *cfg.LenientProviderConfigDecoding
+	}
+	if cfg.LegacyDNSNTPFilesCutoff != nil && !fs.Changed(LegacyDNSNTPFilesCutoffFlag) {
+		a.LegacyDNSNTPFilesCutoff = cfg.LegacyDNSNTPFilesCutoff.UTC().Format(time.RFC3339)
+	}
+
+	// profiles of the --os-profiles file are registered after the profiles of the configuration and replace them
+	a.profiles = nil
+	for _, p := range cfg.Profiles {
+		a.profiles = append(a.profiles, operatingsystemconfig.Profile{
+			Type:           p.Type,
+			Resolver:       operatingsystemconfig.Resolver(p.Resolver),
+			LinkResolvConf: p.LinkResolvConf,
+			TimeDaemon:     operatingsystemconfig.TimeDaemon(p.TimeDaemon),
+			Containerd: operatingsystemconfig.ContainerdLayout{
+				RegistryConfigPath: p.Containerd.RegistryConfigPath,
+				ImageConfig:        p.Containerd.ImageConfig,
+			},
+			IgnitionVersion: ignition.SpecVersion(p.IgnitionVersion),
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig/ignition"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// IgnitionVersionFlag is the name of the command line flag to specify the rendered ignition spec version.
+	IgnitionVersionFlag = "ignition-version"
+	// IgnitionCompressionThresholdFlag is the name of the command line flag to specify the minimum file size to be compressed in the userdata.
+	IgnitionCompressionThresholdFlag = "ignition-compression-threshold"
+	// IgnitionStrictFlag is the name of the command line flag to fail rendering ignition configs with warnings.
+	IgnitionStrictFlag = "ignition-strict"
+	// OSProfilesFlag is the name of the command line flag to specify a file containing additional operating system profiles.
+	OSProfilesFlag = "os-profiles"
+	// MaxUserDataSizeFlag is the name of the command line flag to specify the maximum size of the userdata.
+	MaxUserDataSizeFlag = "max-userdata-size"
+	// AllowedFilePathsFlag is the name of the command line flag to specify the paths the rendered extension files may be written to.
+	AllowedFilePathsFlag = "allowed-file-paths"
+	// LenientProviderConfigDecodingFlag is the name of the command line flag to accept provider configs with unknown or duplicate fields.
+	LenientProviderConfigDecodingFlag = "lenient-provider-config-decoding"
+	// LegacyDNSNTPFilesCutoffFlag is the name of the command line flag to specify the point in time as of which created shoots no longer get the legacy DNS and NTP files.
+	LegacyDNSNTPFilesCutoffFlag = "legacy-dns-ntp-files-cutoff"
+)
+
+// ActuatorOptions are command line options that can be set for operatingsystemconfig.ActuatorOptions.
+type ActuatorOptions struct {
+	// IgnitionVersion is the ignition spec version of the rendered provision userdata.
+	IgnitionVersion string
+	// IgnitionCompressionThreshold is the minimum size in bytes of a file's content to be compressed in the userdata.
+	IgnitionCompressionThreshold int
+	// IgnitionStrict fails rendering the provision userdata if the ignition config has warnings.
+	IgnitionStrict bool
+	// MaxUserDataSize is the maximum size of the provision userdata in bytes.
+	MaxUserDataSize int
+	// OSProfiles is the path to a file containing operating system profiles in addition to the default profiles.
+	OSProfiles string
+	// AllowedFilePaths are the paths the rendered extension files may be written to.
+	AllowedFilePaths []string
+	// LenientProviderConfigDecoding accepts provider configs with unknown or duplicate fields.
+	LenientProviderConfigDecoding bool
+	// LegacyDNSNTPFilesCutoff is the RFC 3339 point in time as of which created shoots no longer get the legacy DNS and NTP files.
+	LegacyDNSNTPFilesCutoff string
+
+	// profiles are registered in addition to the default profiles before the profiles of OSProfiles.
+	profiles []operatingsystemconfig.Profile
+	config   *ActuatorConfig
+}
+
+// AddFlags implements Flagger.AddFlags.
+func (a *ActuatorOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&a.IgnitionVersion, IgnitionVersionFlag, string(ignition.DefaultSpecVersion), fmt.Sprintf("The ignition spec version of the rendered userdata. %v", ignition.SupportedSpecVersions()))
+	fs.IntVar(&a.IgnitionCompressionThreshold, IgnitionCompressionThresholdFlag, 0, "The minimum size in bytes of a file's content to be compressed with gzip in the userdata. Compression is disabled if set to 0.")
+	fs.BoolVar(&a.IgnitionStrict, IgnitionStrictFlag, false, "Fail rendering the userdata if the ignition config has warnings instead of only reporting them.")
+	fs.IntVar(&a.MaxUserDataSize, MaxUserDataSizeFlag, 0, "The maximum size in bytes of the rendered userdata. The size is not limited if set to 0.")
+	fs.StringVar(&a.OSProfiles, OSProfilesFlag, "", "Path to a yaml file containing a list of operating system profiles, which are registered in addition to the default profiles. A profile replaces the default profile of the same type.")
+	fs.StringSliceVar(&a.AllowedFilePaths, AllowedFilePathsFlag, operatingsystemconfig.DefaultAllowedPaths(), "The paths the rendered extension files may be written to. A path allows the file itself and everything below it.")
+	fs.BoolVar(&a.LenientProviderConfigDecoding, LenientProviderConfigDecodingFlag, false, "Accept provider configs with unknown or duplicate fields and only report them as events instead of failing the reconciliation.")
+	fs.StringVar(&a.LegacyDNSNTPFilesCutoff, LegacyDNSNTPFilesCutoffFlag, "", "The RFC 3339 point in time as of which created shoots no longer get the legacy DNS and NTP files, unless their annotation "+operatingsystemconfig.AnnotationLegacyDNSNTPFiles+" says otherwise. Shoots of any age get them if unset.")
+}
+
+// Complete implements Completer.Complete.
+func (a *ActuatorOptions) Complete() error {
+	version := ignition.SpecVersion(a.IgnitionVersion)
+	if err := ignition.ValidateSpecVersion(version); err != nil {
+		return err
+	}
+
+	if a.IgnitionCompressionThreshold < 0 {
+		return fmt.Errorf("--%s must not be negative", IgnitionCompressionThresholdFlag)
+	}
+	if a.MaxUserDataSize < 0 {
+		return fmt.Errorf("--%s must not be negative", MaxUserDataSizeFlag)
+	}
+
+	if err := operatingsystemconfig.ValidateAllowedPaths(a.AllowedFilePaths); err != nil {
+		return fmt.Errorf("invalid --%s: %w", AllowedFilePathsFlag, err)
+	}
+
+	var legacyDNSNTPFilesCutoff time.Time
+	if a.LegacyDNSNTPFilesCutoff != "" {
+		cutoff, err := time.Parse(time.RFC3339, a.LegacyDNSNTPFilesCutoff)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %w", LegacyDNSNTPFilesCutoffFlag, err)
+		}
+		legacyDNSNTPFilesCutoff = cutoff
+	}
+
+	profiles := operatingsystemconfig.DefaultRegistry()
+	for _, p := range a.profiles {
+		if err := profiles.Register(p); err != nil {
+			return fmt.Errorf("invalid --%s: %w", ConfigFlag, err)
+		}
+	}
+
+	if a.OSProfiles != "" {
+		raw, err := os.ReadFile(a.OSProfiles)
+		if err != nil {
+			return fmt.Errorf("unable to read --%s: %w", OSProfilesFlag, err)
+		}
+
+		var additional []operatingsystemconfig.Profile
+		if err := yaml.UnmarshalStrict(raw, &additional); err != nil {
+			return fmt.Errorf("unable to parse --%s: %w", OSProfilesFlag, err)
+		}
+
+		for _, p := range additional {
+			if err := profiles.Register(p); err != nil {
+				return fmt.Errorf("invalid --%s: %w", OSProfilesFlag, err)
+			}
+		}
+	}
+
+	a.config = &ActuatorConfig{
+		IgnitionVersion:              version,
+		IgnitionCompressionThreshold: a.IgnitionCompressionThreshold,
+		IgnitionStrict:               a.IgnitionStrict,
+		MaxUserDataSize:              a.MaxUserDataSize,
+		Profiles:                     profiles,
+		AllowedPaths:                 a.AllowedFilePaths,
+		LenientDecoding:              a.LenientProviderConfigDecoding,
+		LegacyDNSNTPFilesCutoff:      legacyDNSNTPFilesCutoff,
+	}
+	return nil
+}
+
+// Completed returns the completed ActuatorConfig. Only call this if `Complete` was successful.
+func (a *ActuatorOptions) Completed() *ActuatorConfig {
+	return a.config
+}
+
+// ActuatorConfig is a completed actuator configuration.
+type ActuatorConfig struct {
+	// IgnitionVersion is the ignition spec version of the rendered provision userdata.
+	IgnitionVersion ignition.SpecVersion
+	// IgnitionCompressionThreshold is the minimum size in bytes of a file's content to be compressed in the userdata.
+	IgnitionCompressionThreshold int
+	// IgnitionStrict fails rendering the provision userdata if the ignition config has warnings.
+	IgnitionStrict bool
+	// MaxUserDataSize is the maximum size of the provision userdata in bytes.
+	MaxUserDataSize int
+	// Profiles contains the profiles of the handled operating system types.
+	Profiles *operatingsystemconfig.Registry
+	// AllowedPaths are the paths the rendered extension files may be written to.
+	AllowedPaths []string
+	// LenientDecoding accepts provider configs with unknown or duplicate fields.
+	LenientDecoding bool
+	// LegacyDNSNTPFilesCutoff is the point in time as of which created shoots no longer get the legacy DNS and NTP files.
+	LegacyDNSNTPFilesCutoff time.Time
+}
+
+// Apply sets the values of this ActuatorConfig in the given operatingsystemconfig.ActuatorOptions.
+func (a *ActuatorConfig) Apply(opts *operatingsystemconfig.ActuatorOptions) {
+	opts.Ignition.Version = a.IgnitionVersion
+	opts.Ignition.CompressionThreshold = a.IgnitionCompressionThreshold
+	opts.Ignition.Strict = a.IgnitionStrict
+	opts.MaxUserDataSize = a.MaxUserDataSize
+	opts.Profiles = a.Profiles
+	opts.AllowedPaths = a.AllowedPaths
+	opts.LenientDecoding = a.LenientDecoding
+	opts.LegacyDNSNTPFilesCutoff = a.LegacyDNSNTPFilesCutoff
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	controllercmd "github.com/gardener/gardener/extensions/pkg/controller/cmd"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+// RenderOptions are command line options for rendering an OperatingSystemConfig without a cluster.
+type RenderOptions struct {
+	// OperatingSystemConfig is the path to the OperatingSystemConfig manifest to render.
+	OperatingSystemConfig string
+	// ProviderConfig is the path to an ImageProviderConfig manifest overriding the provider config of the OperatingSystemConfig.
+	ProviderConfig string
+	// Secrets are the paths to Secret manifests referenced by files of the OperatingSystemConfig.
+	Secrets []string
+	// Cluster is the path to the extensions Cluster manifest of the shoot the OperatingSystemConfig belongs to.
+	Cluster string
+	// Purpose overrides the purpose of the OperatingSystemConfig.
+	Purpose string
+}
+
+// AddFlags implements Flagger.AddFlags.
+func (r *RenderOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&r.OperatingSystemConfig, "file", "f", "", "Path to the OperatingSystemConfig manifest to render.")
+	fs.StringVar(&r.ProviderConfig, "provider-config", "", "Path to an ImageProviderConfig manifest, overrides the provider config of the OperatingSystemConfig.")
+	fs.StringSliceVar(&r.Secrets, "secret", nil, "Path to a Secret manifest referenced by files of the OperatingSystemConfig, can be specified multiple times.")
+	fs.StringVar(&r.Cluster, "cluster", "", "Path to the extensions Cluster manifest of the shoot, without it the OperatingSystemConfig is rendered as for a shoot of an unknown Kubernetes version.")
+	fs.StringVar(&r.Purpose, "purpose", "", fmt.Sprintf("Overrides the purpose of the OperatingSystemConfig, one of %q or %q.", extensionsv1alpha1.OperatingSystemConfigPurposeProvision, extensionsv1alpha1.OperatingSystemConfigPurposeReconcile))
+}
+
+// Complete implements Completer.Complete.
+func (r *RenderOptions) Complete() error {
+	if r.OperatingSystemConfig == "" {
+		return fmt.Errorf("--file must be specified")
+	}
+
+	switch extensionsv1alpha1.OperatingSystemConfigPurpose(r.Purpose) {
+	case "", extensionsv1alpha1.OperatingSystemConfigPurposeProvision, extensionsv1alpha1.OperatingSystemConfigPurposeReconcile:
+		return nil
+	default:
+		return fmt.Errorf("unknown purpose %q", r.Purpose)
+	}
+}
+
+// NewRenderCommand returns a new command rendering an OperatingSystemConfig manifest with the same logic as the
+// controller, which is useful to debug the userdata of a node without deploying the extension.
+func NewRenderCommand(ctx context.Context) *cobra.Command {
+	var (
+		renderOpts   = &RenderOptions{}
+		configOpts   = &ConfigOptions{}
+		actuatorOpts = &ActuatorOptions{}
+
+		aggOption = controllercmd.NewOptionAggregator(renderOpts, actuatorOpts)
+	)
+
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Renders an OperatingSystemConfig manifest without a cluster",
+		Long: `Renders an OperatingSystemConfig manifest in the same way as the controller does.
+For the purpose provision the ignition userdata is printed, for the purpose reconcile the extension units and files.`,
+		Args: cobra.NoArgs,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// flags and args are parsed at this point, the usage does not help with the remaining errors
+			cmd.SilenceUsage = true
+
+			if err := configOpts.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+			if err := configOpts.ApplyFeatureGates(cmd.Flags()); err != nil {
+				return err
+			}
+			actuatorOpts.ApplyControllerConfiguration(configOpts.Completed(), cmd.Flags())
+
+			if err := aggOption.Complete(); err != nil {
+				return fmt.Errorf("error completing options: %w", err)
+			}
+
+			opts := operatingsystemconfig.ActuatorOptions{}
+			actuatorOpts.Completed().Apply(&opts)
+
+			return render(ctx, renderOpts, opts, cmd.OutOrStdout())
+		},
+	}
+
+	configOpts.AddFlags(cmd.Flags())
+	aggOption.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func render(ctx context.Context, renderOpts *RenderOptions, opts operatingsystemconfig.ActuatorOptions, out io.Writer) error {
+	osc := &extensionsv1alpha1.OperatingSystemConfig{}
+	if err := readManifest(renderOpts.OperatingSystemConfig, osc); err != nil {
+		return err
+	}
+
+	if renderOpts.Purpose != "" {
+		osc.Spec.Purpose = extensionsv1alpha1.OperatingSystemConfigPurpose(renderOpts.Purpose)
+	}
+
+	if renderOpts.ProviderConfig != "" {
+		raw, err := os.ReadFile(renderOpts.ProviderConfig)
+		if err != nil {
+			return fmt.Errorf("unable to read provider config: %w", err)
+		}
+
+		providerConfig, err := yaml.YAMLToJSON(raw)
+		if err != nil {
+			return fmt.Errorf("unable to parse provider config: %w", err)
+		}
+
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: providerConfig}
+	}
+
+	var objects []client.Object
+	if renderOpts.Cluster != "" {
+		if osc.Namespace == "" {
+			return fmt.Errorf("the OperatingSystemConfig must have a namespace to be rendered with a cluster")
+		}
+
+		cluster := &extensionsv1alpha1.Cluster{}
+		if err := readManifest(renderOpts.Cluster, cluster); err != nil {
+			return err
+		}
+		// the Cluster of an OperatingSystemConfig is named after its namespace
+		cluster.Name = osc.Namespace
+
+		objects = append(objects, cluster)
+	}
+
+	for _, path := range renderOpts.Secrets {
+		secret := &corev1.Secret{}
+		if err := readManifest(path, secret); err != nil {
+			return err
+		}
+		if secret.Namespace == "" {
+			secret.Namespace = osc.Namespace
+		}
+
+		objects = append(objects, secret)
+	}
+
+	deps := &renderDependencies{
+		client: fakeclient.NewClientBuilder().WithScheme(kubernetes.SeedScheme).WithObjects(objects...).Build(),
+	}
+
+	userData, extensionUnits, extensionFiles, err := operatingsystemconfig.NewActuator(deps, opts).Reconcile(ctx, log.Log.WithName("render"), osc)
+	if err != nil {
+		return err
+	}
+
+	if osc.Spec.Purpose == extensionsv1alpha1.OperatingSystemConfigPurposeProvision {
+		_, err = fmt.Fprintln(out, string(userData))
+		return err
+	}
+
+	result, err := yaml.Marshal(struct {
+		ExtensionUnits []extensionsv1alpha1.Unit `json:"extensionUnits,omitempty"`
+		ExtensionFiles []extensionsv1alpha1.File `json:"extensionFiles,omitempty"`
+	}{
+		ExtensionUnits: extensionUnits,
+		ExtensionFiles: extensionFiles,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(result)
+	return err
+}
+
+func readManifest(path string, into any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read manifest: %w", err)
+	}
+
+	if err := yaml.Unmarshal(raw, into); err != nil {
+		return fmt.Errorf("unable to parse manifest %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// renderDependencies provide the actuator with its dependencies without a cluster. Secrets are served from memory,
+// events are discarded.
+type renderDependencies struct {
+	client client.Client
+}
+
+func (d *renderDependencies) GetClient() client.Client {
+	return d.client
+}
+
+func (d *renderDependencies) GetEventRecorderFor(string) record.EventRecorder {
+	return discardRecorder{}
+}
+
+// discardRecorder is an event recorder discarding all events.
+type discardRecorder struct{}
+
+var _ record.EventRecorder = discardRecorder{}
+
+func (discardRecorder) Event(runtime.Object, string, string, string) {
+}
+
+func (discardRecorder) Eventf(runtime.Object, string, string, string, ...any) {
+}
+
+func (discardRecorder) AnnotatedEventf(runtime.Object, map[string]string, string, string, string, ...any) {
+}
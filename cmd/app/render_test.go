@@ -0,0 +1,123 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/metal-stack/os-metal-extension/cmd/app"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Render", func() {
+	const (
+		oscManifest     = "../../example/operatingsystemconfig.yaml"
+		clusterManifest = "../../example/cluster.yaml"
+	)
+
+	var (
+		ctx = context.TODO()
+
+		render = func(args ...string) (string, error) {
+			var out bytes.Buffer
+
+			cmd := app.NewRenderCommand(ctx)
+			cmd.SetArgs(args)
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+
+			err := cmd.Execute()
+			return out.String(), err
+		}
+
+		writeManifest = func(content string) string {
+			path := filepath.Join(GinkgoT().TempDir(), "manifest.yaml")
+			Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+			return path
+		}
+	)
+
+	It("should print the provision userdata", func() {
+		out, err := render("-f", oscManifest)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out).To(HavePrefix(`{"ignition":{`))
+		Expect(out).To(ContainSubstring(`"version":"2.3.0"`))
+		Expect(out).To(ContainSubstring(`"path":"/etc/sysctl.d/99-k8s-general.conf"`))
+		Expect(out).To(ContainSubstring(`"name":"docker-monitor.service"`))
+	})
+
+	It("should print the ignition version given as flag", func() {
+		out, err := render("-f", oscManifest, "--ignition-version", "3.4.0")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out).To(ContainSubstring(`"version":"3.4.0"`))
+	})
+
+	It("should print the extension units and files of the purpose reconcile", func() {
+		providerConfig := writeManifest(`apiVersion: metal.provider.extensions.gardener.cloud/v1alpha1
+kind: ImageProviderConfig
+networkIsolation:
+  dnsServers:
+  - 1.1.1.1
+  registryMirrors:
+  - name: metal-stack registry
+    endpoint: https://r.metal-stack.dev
+    ip: 1.2.3.4
+    port: 443
+    mirrorOf:
+    - ghcr.io
+`)
+
+		out, err := render("-f", oscManifest, "--purpose", "reconcile", "--provider-config", providerConfig, "--cluster", clusterManifest)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out).To(HavePrefix("extensionFiles:\n"))
+		Expect(out).To(ContainSubstring("path: /etc/systemd/resolved.conf.d/dns.conf"))
+		Expect(out).To(ContainSubstring("path: /etc/os-metal/hosts"))
+		Expect(out).To(ContainSubstring("name: os-metal-pin-hosts.service"))
+		Expect(out).NotTo(ContainSubstring(`"ignition"`))
+	})
+
+	It("should print only the pinned hosts for the purpose reconcile without registry mirrors", func() {
+		out, err := render("-f", oscManifest, "--purpose", "reconcile")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out).To(ContainSubstring("path: /etc/os-metal/hosts"))
+		Expect(out).To(ContainSubstring("name: os-metal-pin-hosts.service"))
+		Expect(out).NotTo(ContainSubstring("path: /etc/systemd/resolved.conf.d/dns.conf"))
+	})
+
+	DescribeTable("should fail for invalid input",
+		func(args func() []string, wantErr string) {
+			_, err := render(args()...)
+			Expect(err).To(MatchError(ContainSubstring(wantErr)))
+		},
+		Entry("missing manifest flag", func() []string { return nil }, "--file must be specified"),
+		Entry("unknown purpose", func() []string { return []string{"-f", oscManifest, "--purpose", "foo"} }, `unknown purpose "foo"`),
+		Entry("unknown flag", func() []string { return []string{"-f", oscManifest, "--foo"} }, "unknown flag: --foo"),
+		Entry("unexpected argument", func() []string { return []string{"-f", oscManifest, "foo"} }, `unknown command "foo"`),
+		Entry("non-existing manifest", func() []string { return []string{"-f", "does-not-exist.yaml"} }, "unable to read manifest"),
+		Entry("malformed manifest", func() []string { return []string{"-f", writeManifest("spec: [")} }, "unable to parse manifest"),
+		Entry("invalid provider config", func() []string {
+			return []string{"-f", oscManifest, "--provider-config", writeManifest("networkIsolation:\n  dnsServers:\n  - foo\n")}
+		}, `invalid provider config: providerConfig.networkIsolation.dnsServers[0]: Invalid value: "foo"`),
+		Entry("cluster without namespace", func() []string {
+			return []string{"-f", writeManifest("spec:\n  type: metal\n  purpose: provision\n"), "--cluster", clusterManifest}
+		}, "the OperatingSystemConfig must have a namespace to be rendered with a cluster"),
+		Entry("unsupported ignition version", func() []string { return []string{"-f", oscManifest, "--ignition-version", "1.0.0"} }, "1.0.0"),
+	)
+
+	It("should print the usage for flag errors only", func() {
+		out, err := render("-f", oscManifest, "--foo")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(HavePrefix("Usage:"))
+
+		out, err = render("-f", "does-not-exist.yaml")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(BeEmpty())
+	})
+})
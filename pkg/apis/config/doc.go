@@ -0,0 +1,5 @@
+// +k8s:deepcopy-gen=package
+// +groupName="metal.os.extensions.config.gardener.cloud"
+
+// Package config contains the internal version of the configuration of the controller manager.
+package config // import "github.com/metal-stack/os-metal-extension/pkg/apis/config"
@@ -0,0 +1,56 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/metal-stack/os-metal-extension/pkg/apis/config"
+	"github.com/metal-stack/os-metal-extension/pkg/apis/config/install"
+	"github.com/metal-stack/os-metal-extension/pkg/apis/config/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+var (
+	// Scheme contains the versions of the controller configuration.
+	Scheme *runtime.Scheme
+	// Codec decodes the controller configuration strictly, such that misspelled fields are not silently ignored.
+	Codec runtime.Decoder
+)
+
+func init() {
+	Scheme = runtime.NewScheme()
+	install.Install(Scheme)
+	Codec = serializer.NewCodecFactory(Scheme, serializer.EnableStrict).UniversalDecoder()
+}
+
+// LoadFromFile takes a filename and de-serializes the contents into a defaulted ControllerConfiguration object.
+func LoadFromFile(filename string) (*config.ControllerConfiguration, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return Load(bytes)
+}
+
+// Load takes a byte slice and de-serializes the contents into a defaulted ControllerConfiguration object.
+// Empty data results in the defaulted configuration.
+func Load(data []byte) (*config.ControllerConfiguration, error) {
+	cfg := &config.ControllerConfiguration{}
+
+	if len(data) == 0 {
+		external := &v1alpha1.ControllerConfiguration{}
+		Scheme.Default(external)
+		if err := Scheme.Convert(external, cfg, nil); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+
+	if _, _, err := Codec.Decode(data, nil, cfg); err != nil {
+		return nil, fmt.Errorf("unable to decode controller configuration: %w", err)
+	}
+
+	return cfg, nil
+}
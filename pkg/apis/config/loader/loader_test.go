@@ -0,0 +1,68 @@
+package loader_test
+
+import (
+	"time"
+
+	"github.com/metal-stack/os-metal-extension/pkg/apis/config"
+	. "github.com/metal-stack/os-metal-extension/pkg/apis/config/loader"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	componentbaseconfig "k8s.io/component-base/config"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("Loader", func() {
+	Describe("#Load", func() {
+		It("should default an empty configuration", func() {
+			cfg, err := Load(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cfg.ClientConnection).To(Equal(&componentbaseconfig.ClientConnectionConfiguration{QPS: 100, Burst: 130}))
+			Expect(cfg.ConcurrentSyncs).To(Equal(ptr.To(5)))
+		})
+
+		It("should load and default a configuration", func() {
+			cfg, err := Load([]byte(`apiVersion: metal.os.extensions.config.gardener.cloud/v1alpha1
+kind: ControllerConfiguration
+clientConnection:
+  qps: 50
+profiles:
+- type: almalinux
+  resolver: resolv.conf
+  timeDaemon: chrony
+  containerd:
+    imageConfig: true
+ignition:
+  version: "3.4.0"
+  strict: true
+maxUserDataSize: 16384
+legacyDNSNTPFilesCutoff: "2025-03-01T00:00:00Z"
+`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cfg.ClientConnection).To(Equal(&componentbaseconfig.ClientConnectionConfiguration{QPS: 50, Burst: 130}))
+			Expect(cfg.ConcurrentSyncs).To(Equal(ptr.To(5)))
+			Expect(cfg.Profiles).To(Equal([]config.OperatingSystemProfile{
+				{Type: "almalinux", Resolver: "resolv.conf", TimeDaemon: "chrony", Containerd: config.ContainerdLayout{ImageConfig: true}},
+			}))
+			Expect(cfg.Ignition).To(Equal(config.IgnitionConfiguration{Version: ptr.To("3.4.0"), Strict: ptr.To(true)}))
+			Expect(cfg.MaxUserDataSize).To(Equal(ptr.To(16384)))
+			Expect(cfg.LegacyDNSNTPFilesCutoff.Time.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("should reject unknown fields", func() {
+			_, err := Load([]byte(`apiVersion: metal.os.extensions.config.gardener.cloud/v1alpha1
+kind: ControllerConfiguration
+concurentSyncs: 10
+`))
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "concurentSyncs"`)))
+		})
+
+		It("should reject other kinds", func() {
+			_, err := Load([]byte(`apiVersion: metal.provider.extensions.config.gardener.cloud/v1alpha1
+kind: ControllerConfiguration
+`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
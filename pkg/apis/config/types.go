@@ -0,0 +1,70 @@
+package config
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ControllerConfiguration defines the configuration of the os-metal controller manager.
+// Command line flags which are set explicitly take precedence over the values of the configuration.
+type ControllerConfiguration struct {
+	metav1.TypeMeta
+
+	// ClientConnection specifies the client connection settings used when communicating with the apiserver.
+	ClientConnection *componentbaseconfig.ClientConnectionConfiguration
+	// ConcurrentSyncs is the maximum number of concurrent reconciliations of OperatingSystemConfigs.
+	ConcurrentSyncs *int
+	// Profiles are operating system profiles registered in addition to the default profiles. A profile replaces the
+	// default profile of the same type, the types of all profiles are handled by the controller.
+	Profiles []OperatingSystemProfile
+	// Ignition contains the defaults of the ignition renderer.
+	Ignition IgnitionConfiguration
+	// MaxUserDataSize is the maximum size of the provision userdata in bytes, the size is not limited if unset.
+	MaxUserDataSize *int
+	// AllowedFilePaths are the paths the rendered extension files may be written to, defaults to the paths of the
+	// built-in configuration.
+	AllowedFilePaths []string
+	// LenientProviderConfigDecoding accepts provider configs with unknown or duplicate fields.
+	LenientProviderConfigDecoding *bool
+	// LegacyDNSNTPFilesCutoff is the point in time as of which created shoots no longer get the legacy DNS and NTP files,
+	// as their worker machines are created with the DNS and NTP configuration through metal-stack.
+	LegacyDNSNTPFilesCutoff *metav1.Time
+	// FeatureGates is a map of feature names to bools that enable or disable features of the extension.
+	FeatureGates map[string]bool
+}
+
+// OperatingSystemProfile declares the capabilities of the images of an operating system type.
+type OperatingSystemProfile struct {
+	// Type is the OperatingSystemConfig type the profile applies to.
+	Type string
+	// Resolver is the way the image resolves names, either resolv.conf or systemd-resolved.
+	Resolver string
+	// LinkResolvConf is set for images using systemd-resolved whose /etc/resolv.conf does not point to systemd-resolved.
+	LinkResolvConf bool
+	// TimeDaemon is the daemon synchronizing the time, either systemd-timesyncd or chrony.
+	TimeDaemon string
+	// Containerd is the containerd layout of the image.
+	Containerd ContainerdLayout
+	// IgnitionVersion is the ignition spec version understood by the image.
+	IgnitionVersion string
+}
+
+// ContainerdLayout describes where containerd of an image reads its configuration from.
+type ContainerdLayout struct {
+	// RegistryConfigPath is the directory containing the registry host configurations.
+	RegistryConfigPath string
+	// ImageConfig is set if the containerd config of the image already reads the registry host configurations.
+	ImageConfig bool
+}
+
+// IgnitionConfiguration contains the defaults of the ignition renderer.
+type IgnitionConfiguration struct {
+	// Version is the ignition spec version of the rendered provision userdata.
+	Version *string
+	// CompressionThreshold is the minimum size in bytes of a file's content to be compressed in the userdata.
+	CompressionThreshold *int
+	// Strict fails rendering the provision userdata if the ignition config has warnings.
+	Strict *bool
+}
@@ -0,0 +1,28 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	componentbaseconfigv1alpha1 "k8s.io/component-base/config/v1alpha1"
+	"k8s.io/utils/ptr"
+)
+
+func addDefaultingFuncs(scheme *runtime.Scheme) error {
+	return RegisterDefaults(scheme)
+}
+
+// SetDefaults_ControllerConfiguration sets defaults for the controller configuration.
+func SetDefaults_ControllerConfiguration(obj *ControllerConfiguration) {
+	if obj.ClientConnection == nil {
+		obj.ClientConnection = &componentbaseconfigv1alpha1.ClientConnectionConfiguration{}
+	}
+	if obj.ClientConnection.QPS == 0 {
+		obj.ClientConnection.QPS = 100
+	}
+	if obj.ClientConnection.Burst == 0 {
+		obj.ClientConnection.Burst = 130
+	}
+
+	if obj.ConcurrentSyncs == nil {
+		obj.ConcurrentSyncs = ptr.To(5)
+	}
+}
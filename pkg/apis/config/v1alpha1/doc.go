@@ -0,0 +1,8 @@
+// +k8s:deepcopy-gen=package
+// +k8s:conversion-gen=github.com/metal-stack/os-metal-extension/pkg/apis/config
+// +k8s:openapi-gen=true
+// +k8s:defaulter-gen=TypeMeta
+
+// Package v1alpha1 contains the configuration of the controller manager.
+// +groupName=metal.os.extensions.config.gardener.cloud
+package v1alpha1 // import "github.com/metal-stack/os-metal-extension/pkg/apis/config/v1alpha1"
@@ -0,0 +1,89 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	componentbaseconfigv1alpha1 "k8s.io/component-base/config/v1alpha1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ControllerConfiguration defines the configuration of the os-metal controller manager.
+// Command line flags which are set explicitly take precedence over the values of the configuration.
+type ControllerConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ClientConnection specifies the client connection settings used when communicating with the apiserver.
+	// +optional
+	ClientConnection *componentbaseconfigv1alpha1.ClientConnectionConfiguration `json:"clientConnection,omitempty"`
+	// ConcurrentSyncs is the maximum number of concurrent reconciliations of OperatingSystemConfigs.
+	// +optional
+	ConcurrentSyncs *int `json:"concurrentSyncs,omitempty"`
+	// Profiles are operating system profiles registered in addition to the default profiles. A profile replaces the
+	// default profile of the same type, the types of all profiles are handled by the controller.
+	// +optional
+	Profiles []OperatingSystemProfile `json:"profiles,omitempty"`
+	// Ignition contains the defaults of the ignition renderer.
+	// +optional
+	Ignition IgnitionConfiguration `json:"ignition,omitempty"`
+	// MaxUserDataSize is the maximum size of the provision userdata in bytes, the size is not limited if unset.
+	// +optional
+	MaxUserDataSize *int `json:"maxUserDataSize,omitempty"`
+	// AllowedFilePaths are the paths the rendered extension files may be written to, defaults to the paths of the
+	// built-in configuration.
+	// +optional
+	AllowedFilePaths []string `json:"allowedFilePaths,omitempty"`
+	// LenientProviderConfigDecoding accepts provider configs with unknown or duplicate fields.
+	// +optional
+	LenientProviderConfigDecoding *bool `json:"lenientProviderConfigDecoding,omitempty"`
+	// LegacyDNSNTPFilesCutoff is the point in time as of which created shoots no longer get the legacy DNS and NTP files,
+	// as their worker machines are created with the DNS and NTP configuration through metal-stack.
+	// +optional
+	LegacyDNSNTPFilesCutoff *metav1.Time `json:"legacyDNSNTPFilesCutoff,omitempty"`
+	// FeatureGates is a map of feature names to bools that enable or disable features of the extension.
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// OperatingSystemProfile declares the capabilities of the images of an operating system type.
+type OperatingSystemProfile struct {
+	// Type is the OperatingSystemConfig type the profile applies to.
+	Type string `json:"type"`
+	// Resolver is the way the image resolves names, either resolv.conf or systemd-resolved.
+	// +optional
+	Resolver string `json:"resolver,omitempty"`
+	// LinkResolvConf is set for images using systemd-resolved whose /etc/resolv.conf does not point to systemd-resolved.
+	// +optional
+	LinkResolvConf bool `json:"linkResolvConf,omitempty"`
+	// TimeDaemon is the daemon synchronizing the time, either systemd-timesyncd or chrony.
+	// +optional
+	TimeDaemon string `json:"timeDaemon,omitempty"`
+	// Containerd is the containerd layout of the image.
+	// +optional
+	Containerd ContainerdLayout `json:"containerd,omitempty"`
+	// IgnitionVersion is the ignition spec version understood by the image.
+	// +optional
+	IgnitionVersion string `json:"ignitionVersion,omitempty"`
+}
+
+// ContainerdLayout describes where containerd of an image reads its configuration from.
+type ContainerdLayout struct {
+	// RegistryConfigPath is the directory containing the registry host configurations.
+	// +optional
+	RegistryConfigPath string `json:"registryConfigPath,omitempty"`
+	// ImageConfig is set if the containerd config of the image already reads the registry host configurations.
+	// +optional
+	ImageConfig bool `json:"imageConfig,omitempty"`
+}
+
+// IgnitionConfiguration contains the defaults of the ignition renderer.
+type IgnitionConfiguration struct {
+	// Version is the ignition spec version of the rendered provision userdata.
+	// +optional
+	Version *string `json:"version,omitempty"`
+	// CompressionThreshold is the minimum size in bytes of a file's content to be compressed in the userdata.
+	// +optional
+	CompressionThreshold *int `json:"compressionThreshold,omitempty"`
+	// Strict fails rendering the provision userdata if the ignition config has warnings.
+	// +optional
+	Strict *bool `json:"strict,omitempty"`
+}
@@ -0,0 +1,191 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// SPDX-FileCopyrightText: SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by conversion-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	unsafe "unsafe"
+
+	config "github.com/metal-stack/os-metal-extension/pkg/apis/config"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	conversion "k8s.io/apimachinery/pkg/conversion"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	componentbaseconfig "k8s.io/component-base/config"
+	configv1alpha1 "k8s.io/component-base/config/v1alpha1"
+)
+
+func init() {
+	localSchemeBuilder.Register(RegisterConversions)
+}
+
+// RegisterConversions adds conversion functions to the given scheme.
+// Public to allow building arbitrary schemes.
+func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*ContainerdLayout)(nil), (*config.ContainerdLayout)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ContainerdLayout_To_config_ContainerdLayout(a.(*ContainerdLayout), b.(*config.ContainerdLayout), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.ContainerdLayout)(nil), (*ContainerdLayout)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_ContainerdLayout_To_v1alpha1_ContainerdLayout(a.(*config.ContainerdLayout), b.(*ContainerdLayout), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ControllerConfiguration)(nil), (*config.ControllerConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ControllerConfiguration_To_config_ControllerConfiguration(a.(*ControllerConfiguration), b.(*config.ControllerConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.ControllerConfiguration)(nil), (*ControllerConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_ControllerConfiguration_To_v1alpha1_ControllerConfiguration(a.(*config.ControllerConfiguration), b.(*ControllerConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*IgnitionConfiguration)(nil), (*config.IgnitionConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_IgnitionConfiguration_To_config_IgnitionConfiguration(a.(*IgnitionConfiguration), b.(*config.IgnitionConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.IgnitionConfiguration)(nil), (*IgnitionConfiguration)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_IgnitionConfiguration_To_v1alpha1_IgnitionConfiguration(a.(*config.IgnitionConfiguration), b.(*IgnitionConfiguration), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*OperatingSystemProfile)(nil), (*config.OperatingSystemProfile)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_OperatingSystemProfile_To_config_OperatingSystemProfile(a.(*OperatingSystemProfile), b.(*config.OperatingSystemProfile), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*config.OperatingSystemProfile)(nil), (*OperatingSystemProfile)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_config_OperatingSystemProfile_To_v1alpha1_OperatingSystemProfile(a.(*config.OperatingSystemProfile), b.(*OperatingSystemProfile), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func autoConvert_v1alpha1_ContainerdLayout_To_config_ContainerdLayout(in *ContainerdLayout, out *config.ContainerdLayout, s conversion.Scope) error {
+	out.RegistryConfigPath = in.RegistryConfigPath
+	out.ImageConfig = in.ImageConfig
+	return nil
+}
+
+// Convert_v1alpha1_ContainerdLayout_To_config_ContainerdLayout is an autogenerated conversion function.
+func Convert_v1alpha1_ContainerdLayout_To_config_ContainerdLayout(in *ContainerdLayout, out *config.ContainerdLayout, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ContainerdLayout_To_config_ContainerdLayout(in, out, s)
+}
+
+func autoConvert_config_ContainerdLayout_To_v1alpha1_ContainerdLayout(in *config.ContainerdLayout, out *ContainerdLayout, s conversion.Scope) error {
+	out.RegistryConfigPath = in.RegistryConfigPath
+	out.ImageConfig = in.ImageConfig
+	return nil
+}
+
+// Convert_config_ContainerdLayout_To_v1alpha1_ContainerdLayout is an autogenerated conversion function.
+func Convert_config_ContainerdLayout_To_v1alpha1_ContainerdLayout(in *config.ContainerdLayout, out *ContainerdLayout, s conversion.Scope) error {
+	return autoConvert_config_ContainerdLayout_To_v1alpha1_ContainerdLayout(in, out, s)
+}
+
+func autoConvert_v1alpha1_ControllerConfiguration_To_config_ControllerConfiguration(in *ControllerConfiguration, out *config.ControllerConfiguration, s conversion.Scope) error {
+	out.ClientConnection = (*componentbaseconfig.ClientConnectionConfiguration)(unsafe.Pointer(in.ClientConnection))
+	out.ConcurrentSyncs = (*int)(unsafe.Pointer(in.ConcurrentSyncs))
+	out.Profiles = *(*[]config.OperatingSystemProfile)(unsafe.Pointer(&in.Profiles))
+	if err := Convert_v1alpha1_IgnitionConfiguration_To_config_IgnitionConfiguration(&in.Ignition, &out.Ignition, s); err != nil {
+		return err
+	}
+	out.MaxUserDataSize = (*int)(unsafe.Pointer(in.MaxUserDataSize))
+	out.AllowedFilePaths = *(*[]string)(unsafe.Pointer(&in.AllowedFilePaths))
+	out.LenientProviderConfigDecoding = (*bool)(unsafe.Pointer(in.LenientProviderConfigDecoding))
+	out.LegacyDNSNTPFilesCutoff = (*v1.Time)(unsafe.Pointer(in.LegacyDNSNTPFilesCutoff))
+	out.FeatureGates = *(*map[string]bool)(unsafe.Pointer(&in.FeatureGates))
+	return nil
+}
+
+// Convert_v1alpha1_ControllerConfiguration_To_config_ControllerConfiguration is an autogenerated conversion function.
+func Convert_v1alpha1_ControllerConfiguration_To_config_ControllerConfiguration(in *ControllerConfiguration, out *config.ControllerConfiguration, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ControllerConfiguration_To_config_ControllerConfiguration(in, out, s)
+}
+
+func autoConvert_config_ControllerConfiguration_To_v1alpha1_ControllerConfiguration(in *config.ControllerConfiguration, out *ControllerConfiguration, s conversion.Scope) error {
+	out.ClientConnection = (*configv1alpha1.ClientConnectionConfiguration)(unsafe.Pointer(in.ClientConnection))
+	out.ConcurrentSyncs = (*int)(unsafe.Pointer(in.ConcurrentSyncs))
+	out.Profiles = *(*[]OperatingSystemProfile)(unsafe.Pointer(&in.Profiles))
+	if err := Convert_config_IgnitionConfiguration_To_v1alpha1_IgnitionConfiguration(&in.Ignition, &out.Ignition, s); err != nil {
+		return err
+	}
+	out.MaxUserDataSize = (*int)(unsafe.Pointer(in.MaxUserDataSize))
+	out.AllowedFilePaths = *(*[]string)(unsafe.Pointer(&in.AllowedFilePaths))
+	out.LenientProviderConfigDecoding = (*bool)(unsafe.Pointer(in.LenientProviderConfigDecoding))
+	out.LegacyDNSNTPFilesCutoff = (*v1.Time)(unsafe.Pointer(in.LegacyDNSNTPFilesCutoff))
+	out.FeatureGates = *(*map[string]bool)(unsafe.Pointer(&in.FeatureGates))
+	return nil
+}
+
+// Convert_config_ControllerConfiguration_To_v1alpha1_ControllerConfiguration is an autogenerated conversion function.
+func Convert_config_ControllerConfiguration_To_v1alpha1_ControllerConfiguration(in *config.ControllerConfiguration, out *ControllerConfiguration, s conversion.Scope) error {
+	return autoConvert_config_ControllerConfiguration_To_v1alpha1_ControllerConfiguration(in, out, s)
+}
+
+func autoConvert_v1alpha1_IgnitionConfiguration_To_config_IgnitionConfiguration(in *IgnitionConfiguration, out *config.IgnitionConfiguration, s conversion.Scope) error {
+	out.Version = (*string)(unsafe.Pointer(in.Version))
+	out.CompressionThreshold = (*int)(unsafe.Pointer(in.CompressionThreshold))
+	out.Strict = (*bool)(unsafe.Pointer(in.Strict))
+	return nil
+}
+
+// Convert_v1alpha1_IgnitionConfiguration_To_config_IgnitionConfiguration is an autogenerated conversion function.
+func Convert_v1alpha1_IgnitionConfiguration_To_config_IgnitionConfiguration(in *IgnitionConfiguration, out *config.IgnitionConfiguration, s conversion.Scope) error {
+	return autoConvert_v1alpha1_IgnitionConfiguration_To_config_IgnitionConfiguration(in, out, s)
+}
+
+func autoConvert_config_IgnitionConfiguration_To_v1alpha1_IgnitionConfiguration(in *config.IgnitionConfiguration, out *IgnitionConfiguration, s conversion.Scope) error {
+	out.Version = (*string)(unsafe.Pointer(in.Version))
+	out.CompressionThreshold = (*int)(unsafe.Pointer(in.CompressionThreshold))
+	out.Strict = (*bool)(unsafe.Pointer(in.Strict))
+	return nil
+}
+
+// Convert_config_IgnitionConfiguration_To_v1alpha1_IgnitionConfiguration is an autogenerated conversion function.
+func Convert_config_IgnitionConfiguration_To_v1alpha1_IgnitionConfiguration(in *config.IgnitionConfiguration, out *IgnitionConfiguration, s conversion.Scope) error {
+	return autoConvert_config_IgnitionConfiguration_To_v1alpha1_IgnitionConfiguration(in, out, s)
+}
+
+func autoConvert_v1alpha1_OperatingSystemProfile_To_config_OperatingSystemProfile(in *OperatingSystemProfile, out *config.OperatingSystemProfile, s conversion.Scope) error {
+	out.Type = in.Type
+	out.Resolver = in.Resolver
+	out.LinkResolvConf = in.LinkResolvConf
+	out.TimeDaemon = in.TimeDaemon
+	if err := Convert_v1alpha1_ContainerdLayout_To_config_ContainerdLayout(&in.Containerd, &out.Containerd, s); err != nil {
+		return err
+	}
+	out.IgnitionVersion = in.IgnitionVersion
+	return nil
+}
+
+// Convert_v1alpha1_OperatingSystemProfile_To_config_OperatingSystemProfile is an autogenerated conversion function.
+func Convert_v1alpha1_OperatingSystemProfile_To_config_OperatingSystemProfile(in *OperatingSystemProfile, out *config.OperatingSystemProfile, s conversion.Scope) error {
+	return autoConvert_v1alpha1_OperatingSystemProfile_To_config_OperatingSystemProfile(in, out, s)
+}
+
+func autoConvert_config_OperatingSystemProfile_To_v1alpha1_OperatingSystemProfile(in *config.OperatingSystemProfile, out *OperatingSystemProfile, s conversion.Scope) error {
+	out.Type = in.Type
+	out.Resolver = in.Resolver
+	out.LinkResolvConf = in.LinkResolvConf
+	out.TimeDaemon = in.TimeDaemon
+	if err := Convert_config_ContainerdLayout_To_v1alpha1_ContainerdLayout(&in.Containerd, &out.Containerd, s); err != nil {
+		return err
+	}
+	out.IgnitionVersion = in.IgnitionVersion
+	return nil
+}
+
+// Convert_config_OperatingSystemProfile_To_v1alpha1_OperatingSystemProfile is an autogenerated conversion function.
+func Convert_config_OperatingSystemProfile_To_v1alpha1_OperatingSystemProfile(in *config.OperatingSystemProfile, out *OperatingSystemProfile, s conversion.Scope) error {
+	return autoConvert_config_OperatingSystemProfile_To_v1alpha1_OperatingSystemProfile(in, out, s)
+}
@@ -0,0 +1,65 @@
+package validation
+
+import (
+	"slices"
+
+	"github.com/metal-stack/os-metal-extension/pkg/apis/config"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateControllerConfiguration validates the given ControllerConfiguration.
+// The profiles, ignition versions and allowed file paths are validated once they are applied to the actuator options,
+// in the same way as if they are given by command line flags.
+func ValidateControllerConfiguration(cfg *config.ControllerConfiguration) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if cfg.ClientConnection != nil {
+		fldPath := field.NewPath("clientConnection")
+		if cfg.ClientConnection.QPS < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("qps"), cfg.ClientConnection.QPS, "must not be negative"))
+		}
+		if cfg.ClientConnection.Burst < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("burst"), cfg.ClientConnection.Burst, "must not be negative"))
+		}
+	}
+
+	if cfg.ConcurrentSyncs != nil && *cfg.ConcurrentSyncs < 1 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("concurrentSyncs"), *cfg.ConcurrentSyncs, "must be at least 1"))
+	}
+
+	types := sets.New[string]()
+	for i, p := range cfg.Profiles {
+		fldPath := field.NewPath("profiles").Index(i).Child("type")
+		switch {
+		case p.Type == "":
+			allErrs = append(allErrs, field.Required(fldPath, "type of the profile must be set"))
+		case types.Has(p.Type):
+			allErrs = append(allErrs, field.Duplicate(fldPath, p.Type))
+		default:
+			types.Insert(p.Type)
+		}
+	}
+
+	if cfg.Ignition.CompressionThreshold != nil && *cfg.Ignition.CompressionThreshold < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("ignition", "compressionThreshold"), *cfg.Ignition.CompressionThreshold, "must not be negative"))
+	}
+
+	if cfg.MaxUserDataSize != nil && *cfg.MaxUserDataSize < 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("maxUserDataSize"), *cfg.MaxUserDataSize, "must not be negative"))
+	}
+
+	var knownFeatures []string
+	for f := range features.AllFeatureGates {
+		knownFeatures = append(knownFeatures, string(f))
+	}
+	slices.Sort(knownFeatures)
+	for name := range cfg.FeatureGates {
+		if !slices.Contains(knownFeatures, name) {
+			allErrs = append(allErrs, field.NotSupported(field.NewPath("featureGates").Key(name), name, knownFeatures))
+		}
+	}
+
+	return allErrs
+}
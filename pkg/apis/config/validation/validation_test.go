@@ -0,0 +1,70 @@
+package validation_test
+
+import (
+	"github.com/metal-stack/os-metal-extension/pkg/apis/config"
+	. "github.com/metal-stack/os-metal-extension/pkg/apis/config/validation"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	componentbaseconfig "k8s.io/component-base/config"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("ControllerConfiguration validation", func() {
+	var cfg *config.ControllerConfiguration
+
+	BeforeEach(func() {
+		cfg = &config.ControllerConfiguration{
+			ClientConnection: &componentbaseconfig.ClientConnectionConfiguration{QPS: 100, Burst: 130},
+			ConcurrentSyncs:  ptr.To(5),
+			Profiles: []config.OperatingSystemProfile{
+				{Type: "almalinux", Resolver: "resolv.conf"},
+			},
+			Ignition: config.IgnitionConfiguration{
+				Version:              ptr.To("3.4.0"),
+				CompressionThreshold: ptr.To(1024),
+			},
+			MaxUserDataSize: ptr.To(16 * 1024),
+		}
+	})
+
+	It("should accept a valid configuration", func() {
+		Expect(ValidateControllerConfiguration(cfg)).To(BeEmpty())
+	})
+
+	It("should accept known feature gates", func() {
+		cfg.FeatureGates = map[string]bool{"LegacyDNSNTPFiles": false, "IgnitionSortedOutput": true}
+
+		Expect(ValidateControllerConfiguration(cfg)).To(BeEmpty())
+	})
+
+	It("should accept an empty configuration", func() {
+		Expect(ValidateControllerConfiguration(&config.ControllerConfiguration{})).To(BeEmpty())
+	})
+
+	DescribeTable("should reject invalid values",
+		func(modify func(), errType field.ErrorType, fieldPath string) {
+			modify()
+
+			Expect(ValidateControllerConfiguration(cfg)).To(ConsistOf(
+				PointTo(MatchFields(IgnoreExtras, Fields{
+					"Type":  Equal(errType),
+					"Field": Equal(fieldPath),
+				})),
+			))
+		},
+		Entry("negative qps", func() { cfg.ClientConnection.QPS = -1 }, field.ErrorTypeInvalid, "clientConnection.qps"),
+		Entry("negative burst", func() { cfg.ClientConnection.Burst = -1 }, field.ErrorTypeInvalid, "clientConnection.burst"),
+		Entry("no concurrent syncs", func() { cfg.ConcurrentSyncs = ptr.To(0) }, field.ErrorTypeInvalid, "concurrentSyncs"),
+		Entry("profile without type", func() {
+			cfg.Profiles = append(cfg.Profiles, config.OperatingSystemProfile{})
+		}, field.ErrorTypeRequired, "profiles[1].type"),
+		Entry("duplicate profile", func() {
+			cfg.Profiles = append(cfg.Profiles, config.OperatingSystemProfile{Type: "almalinux"})
+		}, field.ErrorTypeDuplicate, "profiles[1].type"),
+		Entry("negative compression threshold", func() { cfg.Ignition.CompressionThreshold = ptr.To(-1) }, field.ErrorTypeInvalid, "ignition.compressionThreshold"),
+		Entry("negative max userdata size", func() { cfg.MaxUserDataSize = ptr.To(-1) }, field.ErrorTypeInvalid, "maxUserDataSize"),
+		Entry("unknown feature gate", func() { cfg.FeatureGates = map[string]bool{"Foo": true} }, field.ErrorTypeNotSupported, "featureGates[Foo]"),
+	)
+})
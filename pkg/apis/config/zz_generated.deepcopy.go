@@ -0,0 +1,146 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// SPDX-FileCopyrightText: SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package config
+
+import (
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	componentbaseconfig "k8s.io/component-base/config"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerdLayout) DeepCopyInto(out *ContainerdLayout) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerdLayout.
+func (in *ContainerdLayout) DeepCopy() *ContainerdLayout {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerdLayout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControllerConfiguration) DeepCopyInto(out *ControllerConfiguration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	if in.ClientConnection != nil {
+		in, out := &in.ClientConnection, &out.ClientConnection
+		*out = new(componentbaseconfig.ClientConnectionConfiguration)
+		**out = **in
+	}
+	if in.ConcurrentSyncs != nil {
+		in, out := &in.ConcurrentSyncs, &out.ConcurrentSyncs
+		*out = new(int)
+		**out = **in
+	}
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make([]OperatingSystemProfile, len(*in))
+		copy(*out, *in)
+	}
+	in.Ignition.DeepCopyInto(&out.Ignition)
+	if in.MaxUserDataSize != nil {
+		in, out := &in.MaxUserDataSize, &out.MaxUserDataSize
+		*out = new(int)
+		**out = **in
+	}
+	if in.AllowedFilePaths != nil {
+		in, out := &in.AllowedFilePaths, &out.AllowedFilePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LenientProviderConfigDecoding != nil {
+		in, out := &in.LenientProviderConfigDecoding, &out.LenientProviderConfigDecoding
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LegacyDNSNTPFilesCutoff != nil {
+		in, out := &in.LegacyDNSNTPFilesCutoff, &out.LegacyDNSNTPFilesCutoff
+		*out = (*in).DeepCopy()
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerConfiguration.
+func (in *ControllerConfiguration) DeepCopy() *ControllerConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ControllerConfiguration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionConfiguration) DeepCopyInto(out *IgnitionConfiguration) {
+	*out = *in
+	if in.Version != nil {
+		in, out := &in.Version, &out.Version
+		*out = new(string)
+		**out = **in
+	}
+	if in.CompressionThreshold != nil {
+		in, out := &in.CompressionThreshold, &out.CompressionThreshold
+		*out = new(int)
+		**out = **in
+	}
+	if in.Strict != nil {
+		in, out := &in.Strict, &out.Strict
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionConfiguration.
+func (in *IgnitionConfiguration) DeepCopy() *IgnitionConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatingSystemProfile) DeepCopyInto(out *OperatingSystemProfile) {
+	*out = *in
+	out.Containerd = in.Containerd
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatingSystemProfile.
+func (in *OperatingSystemProfile) DeepCopy() *OperatingSystemProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatingSystemProfile)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,11 @@
+// +k8s:deepcopy-gen=package
+// +groupName="metal.provider.extensions.gardener.cloud"
+
+//go:generate ../../../hack/update-codegen.sh
+
+// Package metal contains the internal version of the provider config understood by the extension.
+// The extension owns this API and does not depend on the types of the gardener-extension-provider-metal. The group and the
+// fields of its ImageProviderConfig are kept wire-compatible, such that the provider configs written by the metal provider
+// can be decoded. The fields only known to this extension, e.g. the TLS configuration of registry mirrors, are never
+// written by the metal provider and have to be set by whoever else writes the provider config.
+package metal // import "github.com/metal-stack/os-metal-extension/pkg/apis/metal"
@@ -0,0 +1,8 @@
+// +k8s:deepcopy-gen=package
+// +k8s:conversion-gen=github.com/metal-stack/os-metal-extension/pkg/apis/metal
+// +k8s:openapi-gen=true
+// +k8s:defaulter-gen=TypeMeta
+
+// Package v1alpha1 contains the provider config understood by the extension.
+// +groupName=metal.provider.extensions.gardener.cloud
+package v1alpha1 // import "github.com/metal-stack/os-metal-extension/pkg/apis/metal/v1alpha1"
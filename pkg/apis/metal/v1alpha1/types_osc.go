@@ -0,0 +1,74 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImageProviderConfig is stored in the OSC's provider config RawExtension
+type ImageProviderConfig struct {
+	// required to convert it to/from RawExtension
+	metav1.TypeMeta `json:",inline"`
+	// NetworkIsolation defines restricted/forbidden networkaccess for worker nodes
+	NetworkIsolation *NetworkIsolation `json:"networkIsolation,omitempty"`
+}
+
+// NetworkIsolation defines configuration for restricted or forbidden clusters.
+type NetworkIsolation struct {
+	// AllowedNetworks is a list of networks which are allowed to connect in restricted or forbidden NetworkIsolated clusters.
+	AllowedNetworks AllowedNetworks `json:"allowedNetworks,omitempty"`
+	// DNSServers
+	DNSServers []string `json:"dnsServers,omitempty"`
+	// NTPServers
+	NTPServers []string `json:"ntpServers,omitempty"`
+	// The registry which serves the images required to create a shoot.
+	RegistryMirrors []RegistryMirror `json:"registryMirrors,omitempty"`
+	// DisableUpstreamFallback disables pulling from the mirrored registries if none of their mirrors is available,
+	// which avoids waiting for the timeout of pulls from registries which are not reachable in isolated networks.
+	DisableUpstreamFallback bool `json:"disableUpstreamFallback,omitempty"`
+	// NodeFirewall renders an nftables ruleset on the worker nodes, which drops all traffic except for the allowed networks,
+	// the DNS and NTP servers and the registry mirrors, such that nodes enforce the isolation even if the surrounding network does not.
+	NodeFirewall bool `json:"nodeFirewall,omitempty"`
+}
+
+// AllowedNetworks is a list of networks which are allowed to connect in restricted or forbidden NetworkIsolated clusters.
+type AllowedNetworks struct {
+	// Ingress defines a list of networks which are allowed for incoming traffic like service type loadbalancer
+	Ingress []string `json:"ingress,omitempty"`
+	// Egress defines a list of networks which are allowed for outgoing traffic
+	Egress []string `json:"egress,omitempty"`
+}
+
+// RegistryMirror is a registry mirroring other registries.
+type RegistryMirror struct {
+	// Name describes this server
+	Name string `json:"name,omitempty"`
+	// Endpoint is typically the url of the registry in the form https://hostname
+	Endpoint string `json:"endpoint,omitempty"`
+	// IP is the ipv4 or ipv6 address of this server
+	IP string `json:"ip,omitempty"`
+	// Port at which port the service is reachable
+	Port int32 `json:"port,omitempty"`
+	// This Registry Mirror mirrors the following registries
+	MirrorOf []string `json:"mirrorOf,omitempty"`
+	// TLS configures the connection to the registry mirror.
+	TLS *RegistryMirrorTLS `json:"tls,omitempty"`
+}
+
+// RegistryMirrorTLS configures the TLS connection to a registry mirror.
+type RegistryMirrorTLS struct {
+	// CABundle is a PEM encoded bundle of certificate authorities the certificate of the mirror is verified with.
+	CABundle *string `json:"caBundle,omitempty"`
+	// CABundleSecretRef references a secret in the namespace of the OperatingSystemConfig containing a PEM encoded
+	// bundle of certificate authorities in the key "ca.crt", as found in TLS secrets issued by cert-manager, hence the secret
+	// of the ClientCertificateSecretRef may be referenced as well.
+	CABundleSecretRef *corev1.LocalObjectReference `json:"caBundleSecretRef,omitempty"`
+	// ClientCertificateSecretRef references a secret in the namespace of the OperatingSystemConfig containing a PEM encoded
+	// client certificate and key in the keys "tls.crt" and "tls.key".
+	// The client certificate is only written to the nodes with the provision userdata.
+	ClientCertificateSecretRef *corev1.LocalObjectReference `json:"clientCertificateSecretRef,omitempty"`
+	// InsecureSkipVerify disables the verification of the certificate of the mirror.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
@@ -0,0 +1,211 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// SPDX-FileCopyrightText: SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by conversion-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	unsafe "unsafe"
+
+	metal "github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	v1 "k8s.io/api/core/v1"
+	conversion "k8s.io/apimachinery/pkg/conversion"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	localSchemeBuilder.Register(RegisterConversions)
+}
+
+// RegisterConversions adds conversion functions to the given scheme.
+// Public to allow building arbitrary schemes.
+func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*AllowedNetworks)(nil), (*metal.AllowedNetworks)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AllowedNetworks_To_metal_AllowedNetworks(a.(*AllowedNetworks), b.(*metal.AllowedNetworks), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*metal.AllowedNetworks)(nil), (*AllowedNetworks)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_metal_AllowedNetworks_To_v1alpha1_AllowedNetworks(a.(*metal.AllowedNetworks), b.(*AllowedNetworks), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ImageProviderConfig)(nil), (*metal.ImageProviderConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ImageProviderConfig_To_metal_ImageProviderConfig(a.(*ImageProviderConfig), b.(*metal.ImageProviderConfig), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*metal.ImageProviderConfig)(nil), (*ImageProviderConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_metal_ImageProviderConfig_To_v1alpha1_ImageProviderConfig(a.(*metal.ImageProviderConfig), b.(*ImageProviderConfig), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*NetworkIsolation)(nil), (*metal.NetworkIsolation)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_NetworkIsolation_To_metal_NetworkIsolation(a.(*NetworkIsolation), b.(*metal.NetworkIsolation), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*metal.NetworkIsolation)(nil), (*NetworkIsolation)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_metal_NetworkIsolation_To_v1alpha1_NetworkIsolation(a.(*metal.NetworkIsolation), b.(*NetworkIsolation), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RegistryMirror)(nil), (*metal.RegistryMirror)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_RegistryMirror_To_metal_RegistryMirror(a.(*RegistryMirror), b.(*metal.RegistryMirror), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*metal.RegistryMirror)(nil), (*RegistryMirror)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_metal_RegistryMirror_To_v1alpha1_RegistryMirror(a.(*metal.RegistryMirror), b.(*RegistryMirror), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RegistryMirrorTLS)(nil), (*metal.RegistryMirrorTLS)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_RegistryMirrorTLS_To_metal_RegistryMirrorTLS(a.(*RegistryMirrorTLS), b.(*metal.RegistryMirrorTLS), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*metal.RegistryMirrorTLS)(nil), (*RegistryMirrorTLS)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_metal_RegistryMirrorTLS_To_v1alpha1_RegistryMirrorTLS(a.(*metal.RegistryMirrorTLS), b.(*RegistryMirrorTLS), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func autoConvert_v1alpha1_AllowedNetworks_To_metal_AllowedNetworks(in *AllowedNetworks, out *metal.AllowedNetworks, s conversion.Scope) error {
+	out.Ingress = *(*[]string)(unsafe.Pointer(&in.Ingress))
+	out.Egress = *(*[]string)(unsafe.Pointer(&in.Egress))
+	return nil
+}
+
+// Convert_v1alpha1_AllowedNetworks_To_metal_AllowedNetworks is an autogenerated conversion function.
+func Convert_v1alpha1_AllowedNetworks_To_metal_AllowedNetworks(in *AllowedNetworks, out *metal.AllowedNetworks, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AllowedNetworks_To_metal_AllowedNetworks(in, out, s)
+}
+
+func autoConvert_metal_AllowedNetworks_To_v1alpha1_AllowedNetworks(in *metal.AllowedNetworks, out *AllowedNetworks, s conversion.Scope) error {
+	out.Ingress = *(*[]string)(unsafe.Pointer(&in.Ingress))
+	out.Egress = *(*[]string)(unsafe.Pointer(&in.Egress))
+	return nil
+}
+
+// Convert_metal_AllowedNetworks_To_v1alpha1_AllowedNetworks is an autogenerated conversion function.
+func Convert_metal_AllowedNetworks_To_v1alpha1_AllowedNetworks(in *metal.AllowedNetworks, out *AllowedNetworks, s conversion.Scope) error {
+	return autoConvert_metal_AllowedNetworks_To_v1alpha1_AllowedNetworks(in, out, s)
+}
+
+func autoConvert_v1alpha1_ImageProviderConfig_To_metal_ImageProviderConfig(in *ImageProviderConfig, out *metal.ImageProviderConfig, s conversion.Scope) error {
+	out.NetworkIsolation = (*metal.NetworkIsolation)(unsafe.Pointer(in.NetworkIsolation))
+	return nil
+}
+
+// Convert_v1alpha1_ImageProviderConfig_To_metal_ImageProviderConfig is an autogenerated conversion function.
+func Convert_v1alpha1_ImageProviderConfig_To_metal_ImageProviderConfig(in *ImageProviderConfig, out *metal.ImageProviderConfig, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ImageProviderConfig_To_metal_ImageProviderConfig(in, out, s)
+}
+
+func autoConvert_metal_ImageProviderConfig_To_v1alpha1_ImageProviderConfig(in *metal.ImageProviderConfig, out *ImageProviderConfig, s conversion.Scope) error {
+	out.NetworkIsolation = (*NetworkIsolation)(unsafe.Pointer(in.NetworkIsolation))
+	return nil
+}
+
+// Convert_metal_ImageProviderConfig_To_v1alpha1_ImageProviderConfig is an autogenerated conversion function.
+func Convert_metal_ImageProviderConfig_To_v1alpha1_ImageProviderConfig(in *metal.ImageProviderConfig, out *ImageProviderConfig, s conversion.Scope) error {
+	return autoConvert_metal_ImageProviderConfig_To_v1alpha1_ImageProviderConfig(in, out, s)
+}
+
+func autoConvert_v1alpha1_NetworkIsolation_To_metal_NetworkIsolation(in *NetworkIsolation, out *metal.NetworkIsolation, s conversion.Scope) error {
+	if err := Convert_v1alpha1_AllowedNetworks_To_metal_AllowedNetworks(&in.AllowedNetworks, &out.AllowedNetworks, s); err != nil {
+		return err
+	}
+	out.DNSServers = *(*[]string)(unsafe.Pointer(&in.DNSServers))
+	out.NTPServers = *(*[]string)(unsafe.Pointer(&in.NTPServers))
+	out.RegistryMirrors = *(*[]metal.RegistryMirror)(unsafe.Pointer(&in.RegistryMirrors))
+	out.DisableUpstreamFallback = in.DisableUpstreamFallback
+	out.NodeFirewall = in.NodeFirewall
+	return nil
+}
+
+// Convert_v1alpha1_NetworkIsolation_To_metal_NetworkIsolation is an autogenerated conversion function.
+func Convert_v1alpha1_NetworkIsolation_To_metal_NetworkIsolation(in *NetworkIsolation, out *metal.NetworkIsolation, s conversion.Scope) error {
+	return autoConvert_v1alpha1_NetworkIsolation_To_metal_NetworkIsolation(in, out, s)
+}
+
+func autoConvert_metal_NetworkIsolation_To_v1alpha1_NetworkIsolation(in *metal.NetworkIsolation, out *NetworkIsolation, s conversion.Scope) error {
+	if err := Convert_metal_AllowedNetworks_To_v1alpha1_AllowedNetworks(&in.AllowedNetworks, &out.AllowedNetworks, s); err != nil {
+		return err
+	}
+	out.DNSServers = *(*[]string)(unsafe.Pointer(&in.DNSServers))
+	out.NTPServers = *(*[]string)(unsafe.Pointer(&in.NTPServers))
+	out.RegistryMirrors = *(*[]RegistryMirror)(unsafe.Pointer(&in.RegistryMirrors))
+	out.DisableUpstreamFallback = in.DisableUpstreamFallback
+	out.NodeFirewall = in.NodeFirewall
+	return nil
+}
+
+// Convert_metal_NetworkIsolation_To_v1alpha1_NetworkIsolation is an autogenerated conversion function.
+func Convert_metal_NetworkIsolation_To_v1alpha1_NetworkIsolation(in *metal.NetworkIsolation, out *NetworkIsolation, s conversion.Scope) error {
+	return autoConvert_metal_NetworkIsolation_To_v1alpha1_NetworkIsolation(in, out, s)
+}
+
+func autoConvert_v1alpha1_RegistryMirror_To_metal_RegistryMirror(in *RegistryMirror, out *metal.RegistryMirror, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Endpoint = in.Endpoint
+	out.IP = in.IP
+	out.Port = in.Port
+	out.MirrorOf = *(*[]string)(unsafe.Pointer(&in.MirrorOf))
+	out.TLS = (*metal.RegistryMirrorTLS)(unsafe.Pointer(in.TLS))
+	return nil
+}
+
+// Convert_v1alpha1_RegistryMirror_To_metal_RegistryMirror is an autogenerated conversion function.
+func Convert_v1alpha1_RegistryMirror_To_metal_RegistryMirror(in *RegistryMirror, out *metal.RegistryMirror, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RegistryMirror_To_metal_RegistryMirror(in, out, s)
+}
+
+func autoConvert_metal_RegistryMirror_To_v1alpha1_RegistryMirror(in *metal.RegistryMirror, out *RegistryMirror, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Endpoint = in.Endpoint
+	out.IP = in.IP
+	out.Port = in.Port
+	out.MirrorOf = *(*[]string)(unsafe.Pointer(&in.MirrorOf))
+	out.TLS = (*RegistryMirrorTLS)(unsafe.Pointer(in.TLS))
+	return nil
+}
+
+// Convert_metal_RegistryMirror_To_v1alpha1_RegistryMirror is an autogenerated conversion function.
+func Convert_metal_RegistryMirror_To_v1alpha1_RegistryMirror(in *metal.RegistryMirror, out *RegistryMirror, s conversion.Scope) error {
+	return autoConvert_metal_RegistryMirror_To_v1alpha1_RegistryMirror(in, out, s)
+}
+
+func autoConvert_v1alpha1_RegistryMirrorTLS_To_metal_RegistryMirrorTLS(in *RegistryMirrorTLS, out *metal.RegistryMirrorTLS, s conversion.Scope) error {
+	out.CABundle = (*string)(unsafe.Pointer(in.CABundle))
+	out.CABundleSecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.CABundleSecretRef))
+	out.ClientCertificateSecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.ClientCertificateSecretRef))
+	out.InsecureSkipVerify = in.InsecureSkipVerify
+	return nil
+}
+
+// Convert_v1alpha1_RegistryMirrorTLS_To_metal_RegistryMirrorTLS is an autogenerated conversion function.
+func Convert_v1alpha1_RegistryMirrorTLS_To_metal_RegistryMirrorTLS(in *RegistryMirrorTLS, out *metal.RegistryMirrorTLS, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RegistryMirrorTLS_To_metal_RegistryMirrorTLS(in, out, s)
+}
+
+func autoConvert_metal_RegistryMirrorTLS_To_v1alpha1_RegistryMirrorTLS(in *metal.RegistryMirrorTLS, out *RegistryMirrorTLS, s conversion.Scope) error {
+	out.CABundle = (*string)(unsafe.Pointer(in.CABundle))
+	out.CABundleSecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.CABundleSecretRef))
+	out.ClientCertificateSecretRef = (*v1.LocalObjectReference)(unsafe.Pointer(in.ClientCertificateSecretRef))
+	out.InsecureSkipVerify = in.InsecureSkipVerify
+	return nil
+}
+
+// Convert_metal_RegistryMirrorTLS_To_v1alpha1_RegistryMirrorTLS is an autogenerated conversion function.
+func Convert_metal_RegistryMirrorTLS_To_v1alpha1_RegistryMirrorTLS(in *metal.RegistryMirrorTLS, out *RegistryMirrorTLS, s conversion.Scope) error {
+	return autoConvert_metal_RegistryMirrorTLS_To_v1alpha1_RegistryMirrorTLS(in, out, s)
+}
@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateImageProviderConfig validates the given ImageProviderConfig.
+func ValidateImageProviderConfig(config *metal.ImageProviderConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if config.NetworkIsolation != nil {
+		allErrs = append(allErrs, ValidateNetworkIsolation(config.NetworkIsolation, fldPath.Child("networkIsolation"))...)
+	}
+
+	return allErrs
+}
+
+// ValidateNetworkIsolation validates the given NetworkIsolation, which is rendered into the configuration of every node.
+func ValidateNetworkIsolation(ni *metal.NetworkIsolation, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, cidr := range ni.AllowedNetworks.Ingress {
+		allErrs = append(allErrs, validateCIDR(cidr, fldPath.Child("allowedNetworks", "ingress").Index(i))...)
+	}
+	for i, cidr := range ni.AllowedNetworks.Egress {
+		allErrs = append(allErrs, validateCIDR(cidr, fldPath.Child("allowedNetworks", "egress").Index(i))...)
+	}
+
+	for i, server := range ni.DNSServers {
+		if _, err := netip.ParseAddr(server); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("dnsServers").Index(i), server, "must be an IPv4 or IPv6 address"))
+		}
+	}
+
+	for i, server := range ni.NTPServers {
+		if _, err := netip.ParseAddr(server); err == nil {
+			continue
+		}
+		if len(validation.IsDNS1123Subdomain(strings.ToLower(server))) > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ntpServers").Index(i), server, "must be an IPv4 or IPv6 address or a hostname"))
+		}
+	}
+
+	for i, m := range ni.RegistryMirrors {
+		allErrs = append(allErrs, validateRegistryMirror(m, fldPath.Child("registryMirrors").Index(i))...)
+	}
+
+	return allErrs
+}
+
+func validateCIDR(cidr string, fldPath *field.Path) field.ErrorList {
+	if _, err := netip.ParsePrefix(cidr); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, cidr, "must be a CIDR")}
+	}
+	return nil
+}
+
+func validateRegistryMirror(m metal.RegistryMirror, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	endpoint, endpointErrs := validateEndpoint(m.Endpoint, fldPath.Child("endpoint"))
+	allErrs = append(allErrs, endpointErrs...)
+
+	if m.IP != "" {
+		if _, err := netip.ParseAddr(m.IP); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ip"), m.IP, "must be an IPv4 or IPv6 address"))
+		}
+	}
+
+	portPath := fldPath.Child("port")
+	switch {
+	case m.Port == 0:
+	case m.Port < 0 || m.Port > 65535:
+		allErrs = append(allErrs, field.Invalid(portPath, m.Port, "must be between 1 and 65535"))
+	case len(endpointErrs) == 0:
+		if port, err := EndpointPort(endpoint); err == nil && port != m.Port {
+			allErrs = append(allErrs, field.Invalid(portPath, m.Port, fmt.Sprintf("must match the port %d of the endpoint %q", port, m.Endpoint)))
+		}
+	}
+
+	for i, of := range m.MirrorOf {
+		ofPath := fldPath.Child("mirrorOf").Index(i)
+
+		host := of
+		if scheme, rest, found := strings.Cut(of, "://"); found {
+			if scheme != "http" && scheme != "https" {
+				allErrs = append(allErrs, field.Invalid(ofPath, of, "must be a registry host with an optional scheme http or https"))
+				continue
+			}
+			host = rest
+		}
+		allErrs = append(allErrs, validateHostPort(host, ofPath, of)...)
+	}
+
+	return allErrs
+}
+
+func validateEndpoint(endpoint string, fldPath *field.Path) (*url.URL, field.ErrorList) {
+	if endpoint == "" {
+		return nil, field.ErrorList{field.Required(fldPath, "endpoint of the registry mirror must be set")}
+	}
+
+	u, err := url.Parse(endpoint)
+	switch {
+	case err != nil:
+		return nil, field.ErrorList{field.Invalid(fldPath, endpoint, fmt.Sprintf("must be a url: %v", err))}
+	case u.Scheme != "http" && u.Scheme != "https":
+		return nil, field.ErrorList{field.Invalid(fldPath, endpoint, "must be a url with scheme http or https")}
+	case u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "":
+		return nil, field.ErrorList{field.Invalid(fldPath, endpoint, "must be a url with a host and without credentials, query or fragment")}
+	}
+
+	return u, validateHostPort(u.Host, fldPath, endpoint)
+}
+
+// validateHostPort validates a host with an optional port, the host is either an IP address or a hostname.
+func validateHostPort(hostPort string, fldPath *field.Path, value string) field.ErrorList {
+	u, err := url.Parse("//" + hostPort)
+	if err != nil || u.Host != hostPort || u.Hostname() == "" {
+		return field.ErrorList{field.Invalid(fldPath, value, "must contain a host with an optional port")}
+	}
+
+	if _, err := netip.ParseAddr(u.Hostname()); err != nil && len(validation.IsDNS1123Subdomain(strings.ToLower(u.Hostname()))) > 0 {
+		return field.ErrorList{field.Invalid(fldPath, value, "must contain an IP address or a hostname")}
+	}
+
+	if p := u.Port(); p != "" {
+		if port, err := strconv.Atoi(p); err != nil || port < 1 || port > 65535 {
+			return field.ErrorList{field.Invalid(fldPath, value, "must contain a port between 1 and 65535")}
+		}
+	}
+
+	return nil
+}
+
+// EndpointPort returns the port of the given registry mirror endpoint, which defaults to the port of its scheme.
+func EndpointPort(u *url.URL) (int32, error) {
+	if p := u.Port(); p != "" {
+		port, err := strconv.ParseInt(p, 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		return int32(port), nil
+	}
+
+	switch u.Scheme {
+	case "https":
+		return 443, nil
+	case "http":
+		return 80, nil
+	default:
+		return 0, fmt.Errorf("unable to determine the port of scheme %q", u.Scheme)
+	}
+}
@@ -20,80 +20,288 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/gardener/gardener/extensions/pkg/controller/operatingsystemconfig"
 	gardenv1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/utils"
 	"github.com/go-logr/logr"
-	metalextensionv1alpha1 "github.com/metal-stack/gardener-extension-provider-metal/pkg/apis/metal/v1alpha1"
+	"github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	metalinstall "github.com/metal-stack/os-metal-extension/pkg/apis/metal/install"
+	metalv1alpha1 "github.com/metal-stack/os-metal-extension/pkg/apis/metal/v1alpha1"
+	metalvalidation "github.com/metal-stack/os-metal-extension/pkg/apis/metal/validation"
 	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig/ignition"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
-const (
-	containerdConfig = `# Generated by os-extension-metal
-version = 2
-imports = ["/etc/containerd/conf.d/*.toml"]
-disabled_plugins = []
+// ActuatorOptions configure how the actuator renders OperatingSystemConfigs.
+type ActuatorOptions struct {
+	// Ignition are the options for rendering the provision userdata.
+	Ignition ignition.Options
+	// MaxUserDataSize is the maximum size of the provision userdata in bytes, a value of zero disables the limit.
+	MaxUserDataSize int
+	// Profiles contains the profiles of the handled operating system types, defaults to the DefaultProfiles.
+	Profiles *Registry
+	// AllowedPaths are the paths the rendered extension files may be written to, defaults to the DefaultAllowedPaths.
+	AllowedPaths []string
+	// LenientDecoding accepts provider configs with unknown or duplicate fields, which are only reported as events.
+	LenientDecoding bool
+	// LegacyDNSNTPFilesCutoff stops rendering the legacy DNS and NTP files for shoots created at or after it,
+	// a zero value disables the cutoff.
+	LegacyDNSNTPFilesCutoff time.Time
+}
 
-[plugins."io.containerd.grpc.v1.cri".registry]
-  config_path = "/etc/containerd/certs.d"
-`
+const (
+	// EventReasonIgnitionWarning is the reason of events recorded for warnings of the rendered ignition config.
+	EventReasonIgnitionWarning = "IgnitionWarning"
+	// EventReasonProviderConfigNotStrict is the reason of events recorded for provider configs accepted by the lenient decoding.
+	EventReasonProviderConfigNotStrict = "ProviderConfigNotStrict"
+	// EventReasonLegacyDNSNTPFiles is the reason of events recorded for OperatingSystemConfigs still depending on the legacy DNS and NTP files.
+	EventReasonLegacyDNSNTPFiles = "LegacyDNSNTPFiles"
 )
 
 type actuator struct {
-	client  client.Client
-	decoder runtime.Decoder
+	client   client.Client
+	decoder  runtime.Decoder
+	recorder record.EventRecorder
+	opts     ActuatorOptions
+}
+
+// Dependencies are the dependencies of the actuator, which are usually provided by a manager.
+type Dependencies interface {
+	// GetClient returns the client for reading the referenced secrets and the cluster of an OperatingSystemConfig.
+	GetClient() client.Client
+	// GetEventRecorderFor returns the recorder for the events of the actuator.
+	GetEventRecorderFor(name string) record.EventRecorder
 }
 
 // NewActuator creates a new Actuator that updates the status of the handled OperatingSystemConfig resources.
-func NewActuator(mgr manager.Manager) operatingsystemconfig.Actuator {
+func NewActuator(deps Dependencies, opts ActuatorOptions) operatingsystemconfig.Actuator {
+	a := newActuator(deps.GetClient(), opts)
+	a.recorder = deps.GetEventRecorderFor(operatingsystemconfig.ControllerName + "-controller")
+	return a
+}
+
+func newActuator(c client.Client, opts ActuatorOptions) *actuator {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(gardenv1beta1.AddToScheme(scheme))
-	decoder := serializer.NewCodecFactory(scheme).UniversalDecoder()
+	metalinstall.Install(scheme)
+	decoder := serializer.NewCodecFactory(scheme, serializer.EnableStrict).UniversalDecoder()
+
+	if opts.Profiles == nil {
+		opts.Profiles = DefaultRegistry()
+	}
+	if opts.AllowedPaths == nil {
+		opts.AllowedPaths = DefaultAllowedPaths()
+	}
 
 	return &actuator{
-		client:  mgr.GetClient(),
+		client:  c,
 		decoder: decoder,
+		// events are dropped, e.g. for the validator, unless NewActuator sets the recorder of the manager
+		recorder: noopRecorder{},
+		opts:     opts,
 	}
 }
 
+// noopRecorder is an event recorder discarding all events.
+type noopRecorder struct{}
+
+var _ record.EventRecorder = noopRecorder{}
+
+func (noopRecorder) Event(runtime.Object, string, string, string) {
+}
+
+func (noopRecorder) Eventf(runtime.Object, string, string, string, ...any) {
+}
+
+func (noopRecorder) AnnotatedEventf(runtime.Object, map[string]string, string, string, string, ...any) {
+}
+
 func (a *actuator) Reconcile(ctx context.Context, log logr.Logger, osc *extensionsv1alpha1.OperatingSystemConfig) ([]byte, []extensionsv1alpha1.Unit, []extensionsv1alpha1.File, error) {
-	imageProviderConfig := &metalextensionv1alpha1.ImageProviderConfig{}
+	start := time.Now()
 
-	networkIsolation := &metalextensionv1alpha1.NetworkIsolation{}
-	if osc.Spec.ProviderConfig != nil {
-		err := decodeProviderConfig(a.decoder, osc.Spec.ProviderConfig, imageProviderConfig)
-		if err != nil {
-			return nil, nil, nil, fmt.Errorf("unable to decode providerConfig")
-		}
+	userData, extensionUnits, extensionFiles, err := a.reconcile(ctx, log, osc)
+	if err != nil {
+		countFailure(err)
+		return nil, nil, nil, err
 	}
-	if imageProviderConfig.NetworkIsolation != nil {
-		networkIsolation = imageProviderConfig.NetworkIsolation
+
+	renderDuration.WithLabelValues(string(osc.Spec.Purpose), osc.Spec.Type).Observe(time.Since(start).Seconds())
+	return userData, extensionUnits, extensionFiles, nil
+}
+
+func (a *actuator) reconcile(ctx context.Context, log logr.Logger, osc *extensionsv1alpha1.OperatingSystemConfig) ([]byte, []extensionsv1alpha1.Unit, []extensionsv1alpha1.File, error) {
+	networkIsolation, profile, err := a.prepare(log, osc)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	extensionFiles := getExtensionFiles(osc, networkIsolation)
+	shoot, err := a.getShootInfo(ctx, osc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	mirrorTLS, err := a.resolveMirrorTLS(ctx, osc, networkIsolation.RegistryMirrors)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	extensionUnits, extensionFiles, err := a.extensions(osc, profile, shoot, networkIsolation, mirrorTLS)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// the remaining clusters depending on the legacy files are reported, such that the migration can be tracked
+	if legacy, reason := legacyDNSNTPFilesPolicy(a.opts.LegacyDNSNTPFilesCutoff, osc, shoot); legacy && dependsOnLegacyDNSNTPFiles(networkIsolation) {
+		log.Info("Rendering legacy DNS and NTP files, the cluster still depends on them", "operatingsystemconfig", client.ObjectKeyFromObject(osc), "reason", reason)
+		a.recorder.Eventf(osc, corev1.EventTypeNormal, EventReasonLegacyDNSNTPFiles, "Rendering legacy DNS and NTP files, the cluster still depends on them: %s", reason)
+	}
+
+	observeRenderedContent(osc, extensionUnits, extensionFiles)
 
 	switch purpose := osc.Spec.Purpose; purpose {
 	case extensionsv1alpha1.OperatingSystemConfigPurposeProvision:
 		osc := osc.DeepCopy()
+		if err := a.resolveSecretRefs(ctx, osc); err != nil {
+			return nil, nil, nil, err
+		}
+		osc.Spec.Units = EnsureUnits(osc.Spec.Units, extensionUnits...)
 		osc.Spec.Files = EnsureFiles(osc.Spec.Files, extensionFiles...)
 
-		userData, err := ignition.New(log).Transpile(osc)
-		return userData, nil, nil, err
+		ignitionOpts := a.opts.Ignition
+		if profile.IgnitionVersion != "" {
+			ignitionOpts.Version = profile.IgnitionVersion
+		}
+		if ignitionOpts.Version == "" {
+			ignitionOpts.Version = ignition.DefaultSpecVersion
+		}
+		ignitionOpts.RegistryConfigPath = profile.containerdRegistryConfigPath()
+
+		transpiler, err := ignition.New(log, ignitionOpts)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		userData, warnings, err := transpiler.Transpile(osc)
+		ignitionWarnings.WithLabelValues(osc.Spec.Type, string(ignitionOpts.Version)).Add(float64(len(warnings)))
+		for _, w := range warnings {
+			log.Info("Rendered ignition config has a warning", "operatingsystemconfig", client.ObjectKeyFromObject(osc), "warning", w)
+			a.recorder.Event(osc, corev1.EventTypeWarning, EventReasonIgnitionWarning, w)
+		}
+		if err != nil {
+			if ignitionOpts.Strict && len(warnings) > 0 {
+				return nil, nil, nil, newFailure(validationFailures, validationFailureIgnitionWarnings, err)
+			}
+			return nil, nil, nil, err
+		}
+
+		log.Info("Rendered provision userdata", "workerPool", osc.Labels[v1beta1constants.LabelWorkerPool], "userDataSize", len(userData), "maxUserDataSize", a.opts.MaxUserDataSize)
+		userDataSize.WithLabelValues(osc.Spec.Type, string(ignitionOpts.Version)).Observe(float64(len(userData)))
+
+		if err := validateUserDataSize(userData, a.opts.MaxUserDataSize); err != nil {
+			return nil, nil, nil, newFailure(validationFailures, validationFailureUserDataSize, err)
+		}
+
+		return userData, nil, nil, nil
 
 	case extensionsv1alpha1.OperatingSystemConfigPurposeReconcile:
-		return nil, nil, extensionFiles, nil
+		return nil, extensionUnits, extensionFiles, nil
 	default:
 		return nil, nil, nil, fmt.Errorf("unknown purpose: %s", purpose)
 	}
 }
 
+// prepare decodes and validates the provider config of the given OperatingSystemConfig,
+// it returns the network isolation to render and the profile of the operating system type.
+func (a *actuator) prepare(log logr.Logger, osc *extensionsv1alpha1.OperatingSystemConfig) (*metal.NetworkIsolation, Profile, error) {
+	imageProviderConfig := &metal.ImageProviderConfig{}
+
+	networkIsolation := &metal.NetworkIsolation{}
+	if osc.Spec.ProviderConfig != nil {
+		err := decodeProviderConfig(a.decoder, osc.Spec.ProviderConfig, imageProviderConfig)
+		if err != nil {
+			if !a.opts.LenientDecoding || !runtime.IsStrictDecodingError(err) {
+				return nil, Profile{}, newFailure(decodeFailures, decodeFailureReason(err), fmt.Errorf("unable to decode providerConfig: %w", err))
+			}
+
+			// the provider config is decoded nevertheless, only the unknown or duplicate fields are dropped
+			log.Info("Provider config is not strictly valid, continuing because of lenient decoding", "operatingsystemconfig", client.ObjectKeyFromObject(osc), "error", err.Error())
+			a.recorder.Event(osc, corev1.EventTypeWarning, EventReasonProviderConfigNotStrict, err.Error())
+		}
+	}
+	if imageProviderConfig.NetworkIsolation != nil {
+		networkIsolation = imageProviderConfig.NetworkIsolation
+	}
+
+	if errs := metalvalidation.ValidateImageProviderConfig(imageProviderConfig, field.NewPath("providerConfig")); len(errs) > 0 {
+		return nil, Profile{}, newFailure(validationFailures, validationFailureProviderConfig, fmt.Errorf("invalid provider config: %w", errs.ToAggregate()))
+	}
+
+	// operating system types without a profile are not watched by the controller but can still be rendered offline,
+	// they are rendered in the same way as before profiles were introduced
+	profile, ok := a.opts.Profiles.Get(osc.Spec.Type)
+	if !ok {
+		profile = Profile{Type: osc.Spec.Type}
+	}
+
+	return networkIsolation, profile, nil
+}
+
+// extensions returns the units and files added by the extension and ensures that they are only written to allowed paths.
+func (a *actuator) extensions(osc *extensionsv1alpha1.OperatingSystemConfig, profile Profile, shoot *shootInfo, networkIsolation *metal.NetworkIsolation, mirrorTLS map[string]mirrorTLS) ([]extensionsv1alpha1.Unit, []extensionsv1alpha1.File, error) {
+	legacyDNSNTPFiles, _ := legacyDNSNTPFilesPolicy(a.opts.LegacyDNSNTPFilesCutoff, osc, shoot)
+
+	extensionUnits, extensionFiles, err := getExtensions(osc, profile, shoot, legacyDNSNTPFiles, networkIsolation, mirrorTLS)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateExtensionPaths(a.opts.AllowedPaths, extensionUnits, extensionFiles); err != nil {
+		return nil, nil, newFailure(validationFailures, validationFailureUnsafePaths, fmt.Errorf("refusing to render unsafe extension files: %w", err))
+	}
+
+	return extensionUnits, extensionFiles, nil
+}
+
+// resolveSecretRefs translates all file contents referencing a secret into inline content,
+// because the secrets are stored in the shoot namespace of the seed and cannot be accessed during provisioning.
+func (a *actuator) resolveSecretRefs(ctx context.Context, osc *extensionsv1alpha1.OperatingSystemConfig) error {
+	for i, file := range osc.Spec.Files {
+		ref := file.Content.SecretRef
+		if ref == nil {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		if err := a.client.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: osc.Namespace}, secret); err != nil {
+			return fmt.Errorf("unable to get secret %q referenced by file %q: %w", ref.Name, file.Path, err)
+		}
+
+		data, ok := secret.Data[ref.DataKey]
+		if !ok {
+			return fmt.Errorf("secret %q referenced by file %q does not contain data key %q", ref.Name, file.Path, ref.DataKey)
+		}
+
+		osc.Spec.Files[i].Content.SecretRef = nil
+		osc.Spec.Files[i].Content.Inline = &extensionsv1alpha1.FileContentInline{
+			Encoding: string(extensionsv1alpha1.B64FileCodecID),
+			Data:     utils.EncodeBase64(data),
+		}
+	}
+
+	return nil
+}
+
 func (a *actuator) Delete(_ context.Context, _ logr.Logger, _ *extensionsv1alpha1.OperatingSystemConfig) error {
 	return nil
 }
@@ -110,131 +318,136 @@ func (a *actuator) Restore(ctx context.Context, log logr.Logger, osc *extensions
 	return a.Reconcile(ctx, log, osc)
 }
 
-func getExtensionFiles(osc *extensionsv1alpha1.OperatingSystemConfig, networkIsolation *metalextensionv1alpha1.NetworkIsolation) []extensionsv1alpha1.File {
-	var extensionFiles []extensionsv1alpha1.File
+// getExtensions returns the units and files added by the extension. The shoot is nil if there is no Cluster resource
+// for the OperatingSystemConfig, then only the defaults for shoots of an unknown Kubernetes version are rendered.
+// The legacy DNS and NTP files are only rendered if legacyDNSNTPFiles is set, see legacyDNSNTPFilesPolicy.
+func getExtensions(osc *extensionsv1alpha1.OperatingSystemConfig, profile Profile, shoot *shootInfo, legacyDNSNTPFiles bool, networkIsolation *metal.NetworkIsolation, mirrorTLS map[string]mirrorTLS) ([]extensionsv1alpha1.Unit, []extensionsv1alpha1.File, error) {
+	var (
+		extensionUnits []extensionsv1alpha1.Unit
+		extensionFiles []extensionsv1alpha1.File
+	)
 
 	if len(networkIsolation.RegistryMirrors) > 0 {
-		// TODO: this is only required for backwards-compatibility before we started to create worker machines with DNS and NTP configuration through metal-stack
-		// otherwise existing machines would lose connectivity because the GNA cleans up the dns and ntp definitions
-		// references https://github.com/metal-stack/gardener-extension-provider-metal/issues/433
-		//
-		// can potentially be cleaned up as soon as there are no worker nodes of isolated clusters anymore that were created without dns and ntp configuration
-		// ideally a point in time should be defined when we add the dns and ntp to the worker hashes to enforce the setting
-
-		dnsFiles := additionalDNSConfFiles(networkIsolation.DNSServers)
-		extensionFiles = append(extensionFiles, dnsFiles...)
-
-		ntpFiles := additionalNTPConfFiles(networkIsolation.NTPServers)
-		extensionFiles = append(extensionFiles, ntpFiles...)
+		// this is only required for backwards-compatibility before we started to create worker machines with DNS and NTP configuration through metal-stack,
+		// it is retired per cluster through the legacyDNSNTPFilesPolicy
+		if legacyDNSNTPFiles {
+			dnsUnits, dnsFiles := additionalDNSConf(dnsProfile(osc, profile), networkIsolation.DNSServers)
+			extensionUnits = append(extensionUnits, dnsUnits...)
+			extensionFiles = append(extensionFiles, dnsFiles...)
+
+			ntpFiles := additionalNTPConfFiles(profile, networkIsolation.NTPServers)
+			extensionFiles = append(extensionFiles, ntpFiles...)
+		}
 	}
 
+	// the pinned hosts are kept for the purpose reconcile without mirrors, such that their entries are removed from running
+	// nodes, new nodes do not have any entries
+	if len(networkIsolation.RegistryMirrors) > 0 || osc.Spec.Purpose == extensionsv1alpha1.OperatingSystemConfigPurposeReconcile {
+		hostsUnits, hostsFiles := pinnedMirrorHosts(networkIsolation.RegistryMirrors)
+		extensionUnits = append(extensionUnits, hostsUnits...)
+		extensionFiles = append(extensionFiles, hostsFiles...)
+	}
+
+	firewallUnits, firewallFiles := nodeFirewall(networkIsolation, shoot.clusterNetworks())
+	extensionUnits = append(extensionUnits, firewallUnits...)
+	extensionFiles = append(extensionFiles, firewallFiles...)
+
 	if osc.Spec.CRIConfig != nil && osc.Spec.CRIConfig.Name == extensionsv1alpha1.CRINameContainerD {
-		// TODO: as soon as all clusters run at least 1.31 we can remove the containerd config.toml override
-		// the file will be fully managed by the GNA and latest metal-os images render the containerd default config
-		if osc.Spec.Purpose == extensionsv1alpha1.OperatingSystemConfigPurposeReconcile && (osc.Spec.CRIConfig.CgroupDriver == nil || *osc.Spec.CRIConfig.CgroupDriver != extensionsv1alpha1.CgroupDriverSystemd) {
+		// whether the config.toml is overridden is decided per shoot, from 1.31 on the file is managed by the GNA
+		// and images with their own containerd config do not require it either
+		if overrideContainerdConfig(osc, profile, shoot) {
+			config, err := newContainerdConfig(profile.containerdRegistryConfigPath()).encode()
+			if err != nil {
+				return nil, nil, err
+			}
+
 			extensionFiles = append(extensionFiles, extensionsv1alpha1.File{
 				Path:        "/etc/containerd/config.toml",
 				Permissions: ptr.To(int32(0644)),
 				Content: extensionsv1alpha1.FileContent{
 					Inline: &extensionsv1alpha1.FileContentInline{
 						Encoding: string(extensionsv1alpha1.PlainFileCodecID),
-						Data:     containerdConfig,
+						Data:     config,
 					},
 				},
 			})
 		}
 
 		if len(networkIsolation.RegistryMirrors) > 0 {
-			extensionFiles = append(extensionFiles, additionalContainerdMirrors(networkIsolation.RegistryMirrors)...)
+			mirrorFiles, err := additionalContainerdMirrors(profile.containerdRegistryConfigPath(), networkIsolation.RegistryMirrors, mirrorTLS, networkIsolation.DisableUpstreamFallback)
+			if err != nil {
+				return nil, nil, err
+			}
+			extensionFiles = append(extensionFiles, mirrorFiles...)
 		}
 	}
 
-	return extensionFiles
+	return extensionUnits, extensionFiles, nil
 }
 
-// decodeProviderConfig decodes the provider config into the given struct
+// overrideContainerdConfig returns whether the containerd config.toml must be rendered by the extension, which is only
+// required for images without their own containerd config, as long as the shoot runs a Kubernetes version before 1.31.
+func overrideContainerdConfig(osc *extensionsv1alpha1.OperatingSystemConfig, profile Profile, shoot *shootInfo) bool {
+	if !features.DefaultFeatureGate.Enabled(features.ContainerdConfigOverride) || profile.Containerd.ImageConfig {
+		return false
+	}
+	if osc.Spec.Purpose != extensionsv1alpha1.OperatingSystemConfigPurposeReconcile {
+		return false
+	}
+	if osc.Spec.CRIConfig.CgroupDriver != nil && *osc.Spec.CRIConfig.CgroupDriver == extensionsv1alpha1.CgroupDriverSystemd {
+		return false
+	}
+
+	return !shoot.kubernetesVersionMatches(constraintK8sGreaterEqual131)
+}
+
+// decodeProviderConfig decodes the provider config into the given struct.
+// Provider configs without apiVersion and kind are decoded as metal v1alpha1 ImageProviderConfig.
+// Unknown or duplicate fields are returned as strict decoding error, which can be checked with runtime.IsStrictDecodingError,
+// the provider config is decoded into the given struct nevertheless.
 func decodeProviderConfig(decoder runtime.Decoder, providerConfig *runtime.RawExtension, into runtime.Object) error {
 	if providerConfig == nil {
 		return nil
 	}
 
-	if _, _, err := decoder.Decode(providerConfig.Raw, nil, into); err != nil {
-		return fmt.Errorf("could not decode provider config: %w", err)
+	defaultGVK := metalv1alpha1.SchemeGroupVersion.WithKind("ImageProviderConfig")
+	_, _, err := decoder.Decode(providerConfig.Raw, &defaultGVK, into)
+	if runtime.IsNotRegisteredError(err) {
+		return fmt.Errorf("expected apiVersion %q and kind %q: %w", defaultGVK.GroupVersion(), defaultGVK.Kind, err)
 	}
 
-	return nil
+	return err
 }
 
-func additionalContainerdMirrors(mirrors []metalextensionv1alpha1.RegistryMirror) []extensionsv1alpha1.File {
-	var files []extensionsv1alpha1.File
-
-	for _, m := range mirrors {
-		for _, of := range m.MirrorOf {
-			content := fmt.Sprintf(`server = "https://%s"
+func additionalNTPConfFiles(profile Profile, ntpServers []string) []extensionsv1alpha1.File {
+	if len(ntpServers) == 0 {
+		return nil
+	}
 
-[host.%q]
-  capabilities = ["pull", "resolve"]
-`, of, m.Endpoint)
+	if profile.timeDaemon() == TimeDaemonChrony {
+		renderedContent := "# Generated by os-extension-metal\n"
+		for _, ntp := range ntpServers {
+			renderedContent += fmt.Sprintf("server %s iburst\n", ntp)
+		}
+		renderedContent += `driftfile /var/lib/chrony/drift
+makestep 1.0 3
+rtcsync
+`
 
-			files = append(files, extensionsv1alpha1.File{
-				Path: fmt.Sprintf("/etc/containerd/certs.d/%s/hosts.toml", of),
+		return []extensionsv1alpha1.File{
+			{
+				Path: "/etc/chrony.conf",
 				Content: extensionsv1alpha1.FileContent{
 					Inline: &extensionsv1alpha1.FileContentInline{
 						Encoding: string(extensionsv1alpha1.PlainFileCodecID),
-						Data:     content,
+						Data:     renderedContent,
 					},
 				},
-			})
-		}
-	}
-
-	return files
-}
-
-func additionalDNSConfFiles(dnsServers []string) []extensionsv1alpha1.File {
-	if len(dnsServers) == 0 {
-		return nil
-	}
-	resolveDNS := strings.Join(dnsServers, " ")
-	systemdResolvedConfd := fmt.Sprintf(`# Generated by os-extension-metal
-[Resolve]
-DNS=%s
-Domain=~.
-`, resolveDNS)
-	resolvConf := "# Generated by os-extension-metal\n"
-	for _, ip := range dnsServers {
-		resolvConf += fmt.Sprintf("nameserver %s\n", ip)
-	}
-
-	// TODO: in osc.Spec.Type we can get the distro "ubuntu", "debian", "nvidia", ...
-	// from this information we should be able to deduce if systemd-resolved is used or not
-
-	return []extensionsv1alpha1.File{
-		{
-			Path: "/etc/systemd/resolved.conf.d/dns.conf",
-			Content: extensionsv1alpha1.FileContent{
-				Inline: &extensionsv1alpha1.FileContentInline{
-					Encoding: string(extensionsv1alpha1.PlainFileCodecID),
-					Data:     systemdResolvedConfd,
-				},
-			},
-		},
-		{
-			Path: "/etc/resolv.conf",
-			Content: extensionsv1alpha1.FileContent{
-				Inline: &extensionsv1alpha1.FileContentInline{
-					Encoding: string(extensionsv1alpha1.PlainFileCodecID),
-					Data:     resolvConf,
-				},
+				Permissions: ptr.To(int32(0644)),
 			},
-		},
+		}
 	}
-}
 
-func additionalNTPConfFiles(ntpServers []string) []extensionsv1alpha1.File {
-	if len(ntpServers) == 0 {
-		return nil
-	}
 	ntps := strings.Join(ntpServers, " ")
 	renderedContent := fmt.Sprintf(`# Generated by os-extension-metal
 [Time]
@@ -255,6 +468,26 @@ NTP=%s
 	}
 }
 
+func EnsureUnits(base []extensionsv1alpha1.Unit, units ...extensionsv1alpha1.Unit) []extensionsv1alpha1.Unit {
+	var res []extensionsv1alpha1.Unit
+
+	res = append(res, base...)
+
+	for _, unit := range units {
+		index := slices.IndexFunc(base, func(elem extensionsv1alpha1.Unit) bool {
+			return elem.Name == unit.Name
+		})
+
+		if index < 0 {
+			res = append(res, unit)
+		} else {
+			res[index] = unit
+		}
+	}
+
+	return res
+}
+
 func EnsureFiles(base []extensionsv1alpha1.File, files ...extensionsv1alpha1.File) []extensionsv1alpha1.File {
 	var res []extensionsv1alpha1.File
 
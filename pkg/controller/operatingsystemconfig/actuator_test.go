@@ -18,16 +18,26 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/gardener/gardener/extensions/pkg/controller/operatingsystemconfig"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
 	"github.com/gardener/gardener/pkg/utils/test"
 	"github.com/go-logr/logr"
-	metalextensionv1alpha1 "github.com/metal-stack/gardener-extension-provider-metal/pkg/apis/metal/v1alpha1"
+	metalv1alpha1 "github.com/metal-stack/os-metal-extension/pkg/apis/metal/v1alpha1"
 	. "github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig/ignition"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -39,6 +49,7 @@ var _ = Describe("Actuator", func() {
 		ctx        = context.TODO()
 		log        = logr.Discard()
 		fakeClient client.Client
+		recorder   *record.FakeRecorder
 		mgr        manager.Manager
 
 		osc                           *extensionsv1alpha1.OperatingSystemConfig
@@ -47,8 +58,9 @@ var _ = Describe("Actuator", func() {
 	)
 
 	BeforeEach(func() {
-		fakeClient = fakeclient.NewClientBuilder().Build()
-		mgr = test.FakeManager{Client: fakeClient}
+		fakeClient = fakeclient.NewClientBuilder().WithScheme(kubernetes.SeedScheme).Build()
+		recorder = record.NewFakeRecorder(10)
+		mgr = test.FakeManager{Client: fakeClient, EventRecorder: recorder}
 
 		osc = &extensionsv1alpha1.OperatingSystemConfig{
 			Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
@@ -61,15 +73,15 @@ var _ = Describe("Actuator", func() {
 			},
 		}
 		isolatedClusterProviderConfig = &runtime.RawExtension{
-			Raw: mustMarshal(&metalextensionv1alpha1.ImageProviderConfig{
-				NetworkIsolation: &metalextensionv1alpha1.NetworkIsolation{
-					AllowedNetworks: metalextensionv1alpha1.AllowedNetworks{
+			Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+				NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+					AllowedNetworks: metalv1alpha1.AllowedNetworks{
 						Ingress: []string{"10.0.0.1/24"},
 						Egress:  []string{"100.0.0.1/24"},
 					},
 					DNSServers: []string{"1.1.1.1", "1.0.0.1"},
 					NTPServers: []string{"134.60.1.27", "134.60.111.110"},
-					RegistryMirrors: []metalextensionv1alpha1.RegistryMirror{
+					RegistryMirrors: []metalv1alpha1.RegistryMirror{
 						{
 							Name:     "metal-stack registry",
 							Endpoint: "https://r.metal-stack.dev",
@@ -96,7 +108,7 @@ var _ = Describe("Actuator", func() {
 	})
 
 	BeforeEach(func() {
-		actuator = NewActuator(mgr)
+		actuator = NewActuator(mgr, ActuatorOptions{})
 	})
 
 	Describe("#Reconcile", func() {
@@ -130,6 +142,355 @@ var _ = Describe("Actuator", func() {
 				Expect(extensionUnits).To(BeEmpty())
 				Expect(extensionFiles).To(BeEmpty())
 			})
+
+			It("renders ignition v3 when configured", func() {
+				actuator = NewActuator(mgr, ActuatorOptions{Ignition: ignition.Options{Version: ignition.SpecVersionV3}})
+
+				userData, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(userData)).To(ContainSubstring(`"version":"3.4.0"`))
+				Expect(string(userData)).To(ContainSubstring("/some/file"))
+				Expect(extensionUnits).To(BeEmpty())
+				Expect(extensionFiles).To(BeEmpty())
+			})
+
+			Describe("files referencing a secret", func() {
+				BeforeEach(func() {
+					osc.Namespace = "shoot--project--name"
+					osc.Spec.Files = append(osc.Spec.Files, extensionsv1alpha1.File{
+						Path: "/etc/secret",
+						Content: extensionsv1alpha1.FileContent{
+							SecretRef: &extensionsv1alpha1.FileContentSecretRef{
+								Name:    "some-secret",
+								DataKey: "token",
+							},
+						},
+					})
+				})
+
+				It("should resolve the secret content", func() {
+					Expect(fakeClient.Create(ctx, &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Namespace: osc.Namespace},
+						Data:       map[string][]byte{"token": []byte("secret-token")},
+					})).To(Succeed())
+
+					userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(userData)).To(ContainSubstring(`"path":"/etc/secret","contents":{"source":"data:,secret-token"`))
+					Expect(osc.Spec.Files[1].Content.SecretRef).NotTo(BeNil(), "the given osc must not be modified")
+				})
+
+				It("should fail if the secret does not exist", func() {
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(ContainSubstring(`unable to get secret "some-secret" referenced by file "/etc/secret"`)))
+				})
+
+				It("should fail if the secret does not contain the data key", func() {
+					Expect(fakeClient.Create(ctx, &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Namespace: osc.Namespace},
+						Data:       map[string][]byte{"other": []byte("secret-token")},
+					})).To(Succeed())
+
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(`secret "some-secret" referenced by file "/etc/secret" does not contain data key "token"`))
+				})
+			})
+
+			Describe("userdata size limit", func() {
+				BeforeEach(func() {
+					osc.Spec.Files = append(osc.Spec.Files, extensionsv1alpha1.File{
+						Path:    "/opt/bin/large",
+						Content: extensionsv1alpha1.FileContent{Inline: &extensionsv1alpha1.FileContentInline{Data: strings.Repeat("a", 1000)}},
+					})
+					osc.Spec.Units = append(osc.Spec.Units, extensionsv1alpha1.Unit{
+						Name:    "large.service",
+						Content: ptr.To(strings.Repeat("b", 200)),
+						DropIns: []extensionsv1alpha1.DropIn{{Name: "10-foo.conf", Content: strings.Repeat("c", 100)}},
+					})
+				})
+
+				It("should return the userdata within the limit", func() {
+					actuator = NewActuator(mgr, ActuatorOptions{MaxUserDataSize: 10000})
+
+					userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(userData).NotTo(BeEmpty())
+				})
+
+				It("should list the largest contributors if the limit is exceeded", func() {
+					actuator = NewActuator(mgr, ActuatorOptions{MaxUserDataSize: 1000})
+
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(MatchRegexp(`^userdata size of \d+ bytes exceeds the maximum of 1000 bytes, largest contributors are: file "/opt/bin/large" \(1008 bytes\), unit "large.service" \(304 bytes\), file "/some/file" \(11 bytes\), unit "some-unit.service" \(5 bytes\)$`)))
+				})
+
+				It("should list the compressed size of compressed files", func() {
+					actuator = NewActuator(mgr, ActuatorOptions{MaxUserDataSize: 300, Ignition: ignition.Options{CompressionThreshold: 500}})
+
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(MatchRegexp(`largest contributors are: unit "large.service" \(304 bytes\), file "/opt/bin/large" \(\d{2} bytes\),`)))
+				})
+
+				It("should list the units and files rendered for files from container images", func() {
+					osc.Spec.Files = append(osc.Spec.Files, extensionsv1alpha1.File{
+						Path:    "/opt/bin/kubelet",
+						Content: extensionsv1alpha1.FileContent{ImageRef: &extensionsv1alpha1.FileContentImageRef{Image: "registry.k8s.io/hyperkube:v1.31.1", FilePathInImage: "/kubelet"}},
+					})
+					actuator = NewActuator(mgr, ActuatorOptions{MaxUserDataSize: 1000})
+
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(And(
+						ContainSubstring(`file "/usr/local/bin/os-metal-extract-from-image"`),
+						MatchRegexp(`unit "os-metal-extract-kubelet-[0-9a-f]{8}.service"`),
+						Not(ContainSubstring(`file "/opt/bin/kubelet"`)),
+					)))
+				})
+			})
+
+			Describe("ignition warnings", func() {
+				BeforeEach(func() {
+					osc.Spec.Units = []extensionsv1alpha1.Unit{{Name: "no-install.service", Content: ptr.To("[Service]\nExecStart=/bin/true")}}
+				})
+
+				It("should record warnings as events", func() {
+					userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(userData).NotTo(BeEmpty())
+
+					Expect(recorder.Events).To(Receive(Equal(`Warning IgnitionWarning warning: unit "no-install.service" is enabled, but has no install section so enable does nothing`)))
+				})
+
+				It("should fail in strict mode", func() {
+					actuator = NewActuator(mgr, ActuatorOptions{Ignition: ignition.Options{Strict: true}})
+
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(`ignition config has warnings in strict mode: warning: unit "no-install.service" is enabled, but has no install section so enable does nothing`))
+
+					Expect(recorder.Events).To(Receive(HavePrefix("Warning IgnitionWarning ")))
+				})
+			})
+
+			Describe("provider config decoding", func() {
+				BeforeEach(func() {
+					osc.Spec.ProviderConfig = &runtime.RawExtension{
+						Raw: []byte(`{"apiVersion":"metal.provider.extensions.gardener.cloud/v1alpha1","kind":"ImageProviderConfig","networkIsolation":{"dnsServer":["1.1.1.1"],"ntpServers":["134.60.1.27"],"registryMirrors":[{"name":"r","endpoint":"https://r.metal-stack.dev","mirrorOf":["ghcr.io"]}]}}`),
+					}
+				})
+
+				It("should reject unknown fields", func() {
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(`unable to decode providerConfig: strict decoding error: unknown field "networkIsolation.dnsServer"`))
+					Expect(runtime.IsStrictDecodingError(errors.Unwrap(errors.Unwrap(err)))).To(BeTrue())
+				})
+
+				It("should reject an unknown kind", func() {
+					osc.Spec.ProviderConfig.Raw = []byte(`{"apiVersion":"metal.provider.extensions.gardener.cloud/v1alpha1","kind":"ControlPlaneConfig"}`)
+
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(HavePrefix(`unable to decode providerConfig: expected apiVersion "metal.provider.extensions.gardener.cloud/v1alpha1" and kind "ImageProviderConfig": no kind "ControlPlaneConfig" is registered`)))
+				})
+
+				It("should accept unknown fields with lenient decoding", func() {
+					actuator = NewActuator(mgr, ActuatorOptions{LenientDecoding: true})
+
+					userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(userData)).To(ContainSubstring("/etc/systemd/timesyncd.conf"))
+
+					Expect(recorder.Events).To(Receive(Equal(`Warning ProviderConfigNotStrict strict decoding error: unknown field "networkIsolation.dnsServer"`)))
+				})
+
+				It("should still reject an unknown kind with lenient decoding", func() {
+					actuator = NewActuator(mgr, ActuatorOptions{LenientDecoding: true})
+					osc.Spec.ProviderConfig.Raw = []byte(`{"apiVersion":"metal.provider.extensions.gardener.cloud/v1alpha1","kind":"ControlPlaneConfig"}`)
+
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			It("links resolv.conf for debian images", func() {
+				osc.Spec.Type = "debian"
+				osc.Spec.ProviderConfig = isolatedClusterProviderConfig
+
+				userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(userData)).To(ContainSubstring(`"name":"os-metal-resolv-conf-link.service"`))
+				Expect(string(userData)).To(ContainSubstring("/etc/systemd/resolved.conf.d/dns.conf"))
+				Expect(string(userData)).NotTo(ContainSubstring(`"path":"/etc/resolv.conf"`))
+			})
+
+			It("pulls images with the registry config path of the profile", func() {
+				profiles, err := NewRegistry(Profile{Type: "almalinux", Containerd: ContainerdLayout{RegistryConfigPath: "/etc/containerd/registries"}})
+				Expect(err).NotTo(HaveOccurred())
+				actuator = NewActuator(mgr, ActuatorOptions{Profiles: profiles})
+				osc.Spec.Type = "almalinux"
+				osc.Spec.Files = append(osc.Spec.Files, extensionsv1alpha1.File{
+					Path:    "/opt/bin/kubelet",
+					Content: extensionsv1alpha1.FileContent{ImageRef: &extensionsv1alpha1.FileContentImageRef{Image: "kubelet:v1.31.1", FilePathInImage: "/kubelet"}},
+				})
+
+				userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(userData)).To(ContainSubstring(`images%20pull%20--hosts-dir%20%22%2Fetc%2Fcontainerd%2Fregistries%22`))
+			})
+
+			It("renders the ignition version of the profile", func() {
+				profiles, err := NewRegistry(Profile{Type: "almalinux", IgnitionVersion: ignition.SpecVersionV3})
+				Expect(err).NotTo(HaveOccurred())
+				actuator = NewActuator(mgr, ActuatorOptions{Profiles: profiles})
+				osc.Spec.Type = "almalinux"
+
+				userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(userData)).To(ContainSubstring(`"version":"3.4.0"`))
+			})
+
+			It("should reject registry mirrors with contradicting ports", func() {
+				osc.Spec.ProviderConfig = &runtime.RawExtension{
+					Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+						NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+							RegistryMirrors: []metalv1alpha1.RegistryMirror{
+								{Name: "metal-stack registry", Endpoint: "https://r.metal-stack.dev", IP: "1.2.3.4", Port: 8443, MirrorOf: []string{"ghcr.io"}},
+							},
+						},
+					}),
+				}
+
+				_, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).To(MatchError(`invalid provider config: providerConfig.networkIsolation.registryMirrors[0].port: Invalid value: 8443: must match the port 443 of the endpoint "https://r.metal-stack.dev"`))
+			})
+
+			It("should not render userdata for an invalid network isolation", func() {
+				osc.Spec.ProviderConfig = &runtime.RawExtension{
+					Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+						NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+							DNSServers: []string{"1.1.1.1,1.0.0.1"},
+							RegistryMirrors: []metalv1alpha1.RegistryMirror{
+								{Name: "metal-stack registry", Endpoint: "https://r.metal-stack.dev", MirrorOf: []string{"ghcr.io"}},
+							},
+						},
+					}),
+				}
+
+				userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).To(MatchError(`invalid provider config: providerConfig.networkIsolation.dnsServers[0]: Invalid value: "1.1.1.1,1.0.0.1": must be an IPv4 or IPv6 address`))
+				Expect(userData).To(BeNil())
+			})
+
+			It("should reject invalid allowed networks", func() {
+				osc.Spec.ProviderConfig = &runtime.RawExtension{
+					Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+						NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+							AllowedNetworks: metalv1alpha1.AllowedNetworks{Egress: []string{"100.0.0.1"}},
+						},
+					}),
+				}
+
+				_, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).To(MatchError(`invalid provider config: providerConfig.networkIsolation.allowedNetworks.egress[0]: Invalid value: "100.0.0.1": must be a CIDR`))
+			})
+
+			It("renders the node firewall", func() {
+				osc.Spec.ProviderConfig = &runtime.RawExtension{
+					Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+						NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+							NodeFirewall:    true,
+							AllowedNetworks: metalv1alpha1.AllowedNetworks{Egress: []string{"100.0.0.0/24"}},
+						},
+					}),
+				}
+
+				userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(userData)).To(ContainSubstring("os-metal-firewall.service"))
+				Expect(string(userData)).To(ContainSubstring("/etc/os-metal/nftables.conf"))
+			})
+
+			It("should reject registry mirrors writing outside of the registry config path", func() {
+				osc.Spec.ProviderConfig = &runtime.RawExtension{
+					Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+						NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+							RegistryMirrors: []metalv1alpha1.RegistryMirror{
+								{Name: "evil", Endpoint: "https://r.metal-stack.dev", MirrorOf: []string{"../../systemd/system"}},
+							},
+						},
+					}),
+				}
+
+				_, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).To(MatchError(`invalid provider config: providerConfig.networkIsolation.registryMirrors[0].mirrorOf[0]: Invalid value: "../../systemd/system": must contain a host with an optional port`))
+			})
+
+			It("should reject extension files outside of the allowed paths", func() {
+				actuator = NewActuator(mgr, ActuatorOptions{AllowedPaths: []string{"/etc/containerd"}})
+				osc.Spec.ProviderConfig = isolatedClusterProviderConfig
+
+				_, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).To(MatchError(HavePrefix("refusing to render unsafe extension files: ")))
+				Expect(err).To(MatchError(ContainSubstring(`extension file "/etc/resolv.conf" is unsafe: path is not below one of the allowed paths /etc/containerd`)))
+			})
+
+			Describe("registry mirrors with tls", func() {
+				BeforeEach(func() {
+					osc.Namespace = "shoot--project--name"
+					osc.Spec.ProviderConfig = &runtime.RawExtension{
+						Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+							TypeMeta: metav1.TypeMeta{APIVersion: metalv1alpha1.SchemeGroupVersion.String(), Kind: "ImageProviderConfig"},
+							NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+								RegistryMirrors: []metalv1alpha1.RegistryMirror{
+									{
+										Name:     "metal-stack registry",
+										Endpoint: "https://r.metal-stack.dev",
+										MirrorOf: []string{"ghcr.io"},
+										TLS: &metalv1alpha1.RegistryMirrorTLS{
+											CABundle:                   ptr.To("inline-ca\n"),
+											CABundleSecretRef:          &corev1.LocalObjectReference{Name: "mirror-ca"},
+											ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "mirror-client"},
+										},
+									},
+								},
+							},
+						}),
+					}
+				})
+
+				It("should render the certificates from the provider config and secrets", func() {
+					Expect(fakeClient.Create(ctx, &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "mirror-ca", Namespace: osc.Namespace},
+						Data:       map[string][]byte{"ca.crt": []byte("secret-ca\n")},
+					})).To(Succeed())
+					Expect(fakeClient.Create(ctx, &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "mirror-client", Namespace: osc.Namespace},
+						Data:       map[string][]byte{"tls.crt": []byte("client-cert"), "tls.key": []byte("client-key")},
+					})).To(Succeed())
+
+					userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(userData)).To(ContainSubstring(`"path":"/etc/containerd/certs.d/ghcr.io/r.metal-stack.dev-ca.crt","contents":{"source":"data:,inline-ca%0Asecret-ca%0A"`))
+					Expect(string(userData)).To(ContainSubstring(`"path":"/etc/containerd/certs.d/ghcr.io/r.metal-stack.dev-client.crt","contents":{"source":"data:,client-cert"`))
+					Expect(string(userData)).To(ContainSubstring(`"path":"/etc/containerd/certs.d/ghcr.io/r.metal-stack.dev-client.key","contents":{"source":"data:,client-key","verification":{}},"mode":384`))
+				})
+
+				It("should fail if a referenced secret does not exist", func() {
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(ContainSubstring(`unable to read ca bundle of registry mirror "metal-stack registry": unable to get secret "mirror-ca"`)))
+				})
+			})
+
+			It("renders ignition v2 by default", func() {
+				userData, _, _, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(userData)).To(ContainSubstring(`"version":"2.3.0"`))
+			})
 		})
 
 		When("purpose is 'reconcile'", func() {
@@ -142,14 +503,26 @@ var _ = Describe("Actuator", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(userData).To(BeEmpty())
-				Expect(extensionUnits).To(BeNil())
-				Expect(extensionFiles).To(ConsistOf(extensionsv1alpha1.File{
-					Path:        "/etc/containerd/config.toml",
-					Permissions: ptr.To(int32(420)),
-					Content: extensionsv1alpha1.FileContent{
-						Inline: &extensionsv1alpha1.FileContentInline{
-							Encoding: string(extensionsv1alpha1.PlainFileCodecID),
-							Data: `# Generated by os-extension-metal
+				Expect(extensionUnits).To(ConsistOf(HaveField("Name", "os-metal-pin-hosts.service")))
+				Expect(extensionFiles).To(ConsistOf(
+					HaveField("Path", "/usr/local/bin/os-metal-pin-hosts"),
+					extensionsv1alpha1.File{
+						Path:        "/etc/os-metal/hosts",
+						Permissions: ptr.To(int32(0644)),
+						Content: extensionsv1alpha1.FileContent{
+							Inline: &extensionsv1alpha1.FileContentInline{
+								Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+								Data:     "# Generated by os-extension-metal\n",
+							},
+						},
+					},
+					extensionsv1alpha1.File{
+						Path:        "/etc/containerd/config.toml",
+						Permissions: ptr.To(int32(420)),
+						Content: extensionsv1alpha1.FileContent{
+							Inline: &extensionsv1alpha1.FileContentInline{
+								Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+								Data: `# Generated by os-extension-metal
 version = 2
 imports = ["/etc/containerd/conf.d/*.toml"]
 disabled_plugins = []
@@ -157,9 +530,10 @@ disabled_plugins = []
 [plugins."io.containerd.grpc.v1.cri".registry]
   config_path = "/etc/containerd/certs.d"
 `,
+							},
 						},
 					},
-				}))
+				))
 			})
 
 			It("does not render containerd config when cgroup driver systemd is set", func() {
@@ -168,12 +542,173 @@ disabled_plugins = []
 					CgroupDriver: ptr.To(extensionsv1alpha1.CgroupDriverSystemd),
 				}
 
-				userData, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, oscCopy)
+				userData, _, extensionFiles, err := actuator.Reconcile(ctx, log, oscCopy)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(userData).To(BeEmpty())
-				Expect(extensionUnits).To(BeNil())
-				Expect(extensionFiles).To(BeEmpty())
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/containerd/config.toml")))
+			})
+
+			It("does not render containerd config if the feature gate is disabled", func() {
+				DeferCleanup(test.WithFeatureGate(features.DefaultFeatureGate, features.ContainerdConfigOverride, false))
+
+				_, _, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/containerd/config.toml")))
+			})
+
+			Describe("cluster", func() {
+				createCluster := func(kubernetesVersion string, meta metav1.ObjectMeta) {
+					osc.Namespace = "shoot--foo--bar"
+					Expect(fakeClient.Create(ctx, &extensionsv1alpha1.Cluster{
+						ObjectMeta: metav1.ObjectMeta{Name: osc.Namespace},
+						Spec: extensionsv1alpha1.ClusterSpec{
+							Shoot: runtime.RawExtension{Raw: mustMarshal(&gardencorev1beta1.Shoot{
+								TypeMeta:   metav1.TypeMeta{APIVersion: gardencorev1beta1.SchemeGroupVersion.String(), Kind: "Shoot"},
+								ObjectMeta: meta,
+								Spec: gardencorev1beta1.ShootSpec{
+									Kubernetes: gardencorev1beta1.Kubernetes{Version: kubernetesVersion},
+								},
+							})},
+						},
+					})).To(Succeed())
+				}
+
+				It("renders containerd config for shoots before kubernetes 1.31", func() {
+					createCluster("1.30.8", metav1.ObjectMeta{})
+
+					_, _, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/containerd/config.toml")))
+				})
+
+				It("does not render containerd config for shoots as of kubernetes 1.31", func() {
+					createCluster("1.31.1", metav1.ObjectMeta{})
+
+					_, _, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/containerd/config.toml")))
+				})
+
+				It("should fail for an invalid kubernetes version", func() {
+					createCluster("foo", metav1.ObjectMeta{})
+
+					_, _, _, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).To(MatchError(ContainSubstring("unable to parse kubernetes version")))
+				})
+
+				Describe("legacy dns and ntp files", func() {
+					cutoff := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+					BeforeEach(func() {
+						osc.Spec.ProviderConfig = isolatedClusterProviderConfig
+						actuator = NewActuator(mgr, ActuatorOptions{LegacyDNSNTPFilesCutoff: cutoff})
+					})
+
+					It("renders the files and records an event for shoots created before the cutoff", func() {
+						createCluster("1.30.8", metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(cutoff.Add(-time.Hour))})
+
+						_, _, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(extensionFiles).To(ContainElements(
+							HaveField("Path", "/etc/systemd/resolved.conf.d/dns.conf"),
+							HaveField("Path", "/etc/resolv.conf"),
+							HaveField("Path", "/etc/systemd/timesyncd.conf"),
+						))
+						Expect(recorder.Events).To(Receive(Equal("Normal LegacyDNSNTPFiles Rendering legacy DNS and NTP files, the cluster still depends on them: shoot was created before the cutoff 2025-03-01T00:00:00Z")))
+					})
+
+					It("does not render the files for shoots created after the cutoff", func() {
+						createCluster("1.30.8", metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(cutoff.Add(time.Hour))})
+
+						_, _, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/systemd/resolved.conf.d/dns.conf")))
+						Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/resolv.conf")))
+						Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/systemd/timesyncd.conf")))
+						Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/os-metal/hosts")))
+						Expect(recorder.Events).To(BeEmpty())
+					})
+
+					It("does not render the files for shoots annotated to stop rendering them", func() {
+						createCluster("1.30.8", metav1.ObjectMeta{
+							CreationTimestamp: metav1.NewTime(cutoff.Add(-time.Hour)),
+							Annotations:       map[string]string{AnnotationLegacyDNSNTPFiles: "false"},
+						})
+
+						_, _, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/systemd/timesyncd.conf")))
+						Expect(recorder.Events).To(BeEmpty())
+					})
+
+					It("retires the files of an existing pool crossing the cutoff", func() {
+						createCluster("1.30.8", metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(cutoff.Add(-time.Hour))})
+
+						_, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/systemd/timesyncd.conf")))
+						Expect(recorder.Events).To(Receive(HaveSuffix("shoot was created before the cutoff 2025-03-01T00:00:00Z")))
+						osc.Status.ExtensionUnits = extensionUnits
+						osc.Status.ExtensionFiles = extensionFiles
+
+						By("reconciling again before crossing the cutoff")
+						_, gotUnits, gotFiles, err := actuator.Reconcile(ctx, log, osc)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(gotUnits).To(Equal(extensionUnits))
+						Expect(gotFiles).To(Equal(extensionFiles))
+						Expect(recorder.Events).To(Receive(HaveSuffix("shoot was created before the cutoff 2025-03-01T00:00:00Z")))
+
+						By("moving the cutoff before the creation of the shoot")
+						actuator = NewActuator(mgr, ActuatorOptions{LegacyDNSNTPFilesCutoff: cutoff.Add(-2 * time.Hour)})
+
+						_, _, gotFiles, err = actuator.Reconcile(ctx, log, osc)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(gotFiles).NotTo(ContainElement(HaveField("Path", "/etc/systemd/resolved.conf.d/dns.conf")))
+						Expect(gotFiles).NotTo(ContainElement(HaveField("Path", "/etc/resolv.conf")))
+						Expect(gotFiles).NotTo(ContainElement(HaveField("Path", "/etc/systemd/timesyncd.conf")))
+						Expect(gotFiles).To(ContainElement(HaveField("Path", "/etc/os-metal/hosts")))
+						Expect(recorder.Events).To(BeEmpty())
+					})
+
+					It("keeps the files of nodes which already got them without a cutoff", func() {
+						actuator = NewActuator(mgr, ActuatorOptions{})
+						createCluster("1.30.8", metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(cutoff.Add(time.Hour))})
+
+						_, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/systemd/timesyncd.conf")))
+						Expect(recorder.Events).To(Receive(HaveSuffix("no cutoff is configured")))
+						osc.Status.ExtensionUnits = extensionUnits
+						osc.Status.ExtensionFiles = extensionFiles
+
+						_, gotUnits, gotFiles, err := actuator.Reconcile(ctx, log, osc)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(gotUnits).To(Equal(extensionUnits))
+						Expect(gotFiles).To(Equal(extensionFiles))
+						Expect(recorder.Events).To(Receive(HaveSuffix("nodes already got the files and keep them until they are replaced")))
+					})
+				})
+			})
+
+			It("does not render the legacy dns and ntp files if the feature gate is disabled", func() {
+				DeferCleanup(test.WithFeatureGate(features.DefaultFeatureGate, features.LegacyDNSNTPFiles, false))
+				osc.Spec.ProviderConfig = isolatedClusterProviderConfig
+
+				_, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(extensionUnits).To(ConsistOf(HaveField("Name", "os-metal-pin-hosts.service")))
+				Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/os-metal/hosts")))
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/systemd/resolved.conf.d/dns.conf")))
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/resolv.conf")))
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/systemd/timesyncd.conf")))
 			})
 
 			It("network isolation files are added", func() {
@@ -184,7 +719,7 @@ disabled_plugins = []
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(string(userData)).To(BeEmpty())
-				Expect(extensionUnits).To(BeEmpty())
+				Expect(extensionUnits).To(ConsistOf(HaveField("Name", "os-metal-pin-hosts.service")))
 				Expect(extensionFiles).To(ConsistOf(
 					extensionsv1alpha1.File{
 						Path: "/etc/systemd/resolved.conf.d/dns.conf",
@@ -220,6 +755,20 @@ nameserver 1.0.0.1
 								Data: `# Generated by os-extension-metal
 [Time]
 NTP=134.60.1.27 134.60.111.110
+`,
+							},
+						},
+					},
+					HaveField("Path", "/usr/local/bin/os-metal-pin-hosts"),
+					extensionsv1alpha1.File{
+						Path:        "/etc/os-metal/hosts",
+						Permissions: ptr.To(int32(0644)),
+						Content: extensionsv1alpha1.FileContent{
+							Inline: &extensionsv1alpha1.FileContentInline{
+								Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+								Data: `# Generated by os-extension-metal
+1.2.3.4 r.metal-stack.dev
+127.0.0.1 localhost
 `,
 							},
 						},
@@ -248,8 +797,9 @@ disabled_plugins = []
 								Encoding: string(extensionsv1alpha1.PlainFileCodecID),
 								Data: `server = "https://ghcr.io"
 
-[host."https://r.metal-stack.dev"]
-  capabilities = ["pull", "resolve"]
+[host]
+  [host."https://r.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
 `,
 							},
 						},
@@ -261,8 +811,9 @@ disabled_plugins = []
 								Encoding: string(extensionsv1alpha1.PlainFileCodecID),
 								Data: `server = "https://quay.io"
 
-[host."https://r.metal-stack.dev"]
-  capabilities = ["pull", "resolve"]
+[host]
+  [host."https://r.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
 `,
 							},
 						},
@@ -272,16 +823,167 @@ disabled_plugins = []
 						Content: extensionsv1alpha1.FileContent{
 							Inline: &extensionsv1alpha1.FileContentInline{
 								Encoding: string(extensionsv1alpha1.PlainFileCodecID),
-								Data: `server = "https://docker.io"
+								Data: `server = "https://registry-1.docker.io"
 
-[host."http://localhost:8080"]
-  capabilities = ["pull", "resolve"]
+[host]
+  [host."http://localhost:8080"]
+    capabilities = ["pull", "resolve"]
 `,
 							},
 						},
 					},
 				))
 			})
+
+			It("renders the node firewall", func() {
+				osc.Spec.ProviderConfig = &runtime.RawExtension{
+					Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+						NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+							NodeFirewall:    true,
+							AllowedNetworks: metalv1alpha1.AllowedNetworks{Egress: []string{"100.0.0.0/24"}},
+							DNSServers:      []string{"1.1.1.1"},
+						},
+					}),
+				}
+
+				_, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(extensionUnits).To(ConsistOf(HaveField("Name", "os-metal-firewall.service"), HaveField("Name", "os-metal-pin-hosts.service")))
+				Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/os-metal/nftables.conf")))
+			})
+
+			It("does not render the client certificates of registry mirrors", func() {
+				osc.Namespace = "shoot--project--name"
+				osc.Spec.ProviderConfig = &runtime.RawExtension{
+					Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{
+						NetworkIsolation: &metalv1alpha1.NetworkIsolation{
+							RegistryMirrors: []metalv1alpha1.RegistryMirror{
+								{
+									Name:     "metal-stack registry",
+									Endpoint: "https://r.metal-stack.dev",
+									MirrorOf: []string{"ghcr.io"},
+									TLS: &metalv1alpha1.RegistryMirrorTLS{
+										CABundle:                   ptr.To("inline-ca\n"),
+										ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "mirror-client"},
+									},
+								},
+							},
+						},
+					}),
+				}
+
+				_, _, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred(), "the client certificate secret is not read")
+
+				Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/containerd/certs.d/ghcr.io/r.metal-stack.dev-ca.crt")))
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", HaveSuffix("-client.crt"))))
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", HaveSuffix("-client.key"))))
+				Expect(extensionFiles).To(ContainElement(And(
+					HaveField("Path", "/etc/containerd/certs.d/ghcr.io/hosts.toml"),
+					HaveField("Content.Inline.Data", ContainSubstring(`client = [["/etc/containerd/certs.d/ghcr.io/r.metal-stack.dev-client.crt", "/etc/containerd/certs.d/ghcr.io/r.metal-stack.dev-client.key"]]`)),
+				)))
+			})
+
+			It("only configures systemd-resolved for ubuntu images", func() {
+				osc.Spec.Type = "ubuntu"
+				osc.Spec.ProviderConfig = isolatedClusterProviderConfig
+
+				_, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(extensionUnits).To(ConsistOf(HaveField("Name", "os-metal-pin-hosts.service")))
+				Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/systemd/resolved.conf.d/dns.conf")))
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/resolv.conf")))
+			})
+
+			It("links resolv.conf to systemd-resolved for debian images", func() {
+				osc.Spec.Type = "debian"
+				osc.Spec.ProviderConfig = isolatedClusterProviderConfig
+
+				_, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(extensionUnits).To(ConsistOf(HaveField("Name", "os-metal-resolv-conf-link.service"), HaveField("Name", "os-metal-pin-hosts.service")))
+				Expect(extensionFiles).To(ContainElement(HaveField("Path", "/etc/systemd/resolved.conf.d/dns.conf")))
+				Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/resolv.conf")))
+			})
+
+			DescribeTable("keeps the resolv.conf of nodes which got the dns configuration of all images",
+				func(osType string) {
+					osc.Spec.Type = osType
+					osc.Spec.ProviderConfig = isolatedClusterProviderConfig
+					// the extension files rendered for all images before the dns configuration depended on the profile
+					osc.Status.ExtensionFiles = []extensionsv1alpha1.File{
+						{
+							Path:    "/etc/systemd/resolved.conf.d/dns.conf",
+							Content: extensionsv1alpha1.FileContent{Inline: &extensionsv1alpha1.FileContentInline{Encoding: string(extensionsv1alpha1.PlainFileCodecID), Data: "# Generated by os-extension-metal\n[Resolve]\nDNS=1.1.1.1 1.0.0.1\nDomain=~.\n"}},
+						},
+						{
+							Path:    "/etc/resolv.conf",
+							Content: extensionsv1alpha1.FileContent{Inline: &extensionsv1alpha1.FileContentInline{Encoding: string(extensionsv1alpha1.PlainFileCodecID), Data: "# Generated by os-extension-metal\nnameserver 1.1.1.1\nnameserver 1.0.0.1\n"}},
+						},
+					}
+
+					_, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(extensionUnits).NotTo(ContainElement(HaveField("Name", "os-metal-resolv-conf-link.service")))
+					Expect(extensionFiles).To(ContainElements(osc.Status.ExtensionFiles), "files missing in the new output are removed from the nodes")
+
+					By("reconciling the new output again")
+					osc.Status.ExtensionUnits, osc.Status.ExtensionFiles = extensionUnits, extensionFiles
+
+					_, unitsAgain, filesAgain, err := actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(unitsAgain).To(Equal(extensionUnits))
+					Expect(filesAgain).To(Equal(extensionFiles))
+
+					By("reconciling the OperatingSystemConfig of new nodes")
+					osc.Status = extensionsv1alpha1.OperatingSystemConfigStatus{}
+
+					_, _, extensionFiles, err = actuator.Reconcile(ctx, log, osc)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(extensionFiles).NotTo(ContainElement(HaveField("Path", "/etc/resolv.conf")))
+				},
+				Entry("ubuntu", "ubuntu"),
+				Entry("debian", "debian"),
+				Entry("nvidia", "nvidia"),
+			)
+
+			It("renders the configuration of a registered profile", func() {
+				profiles, err := NewRegistry(Profile{
+					Type:       "almalinux",
+					Resolver:   ResolverResolvConf,
+					TimeDaemon: TimeDaemonChrony,
+					Containerd: ContainerdLayout{RegistryConfigPath: "/etc/containerd/registries", ImageConfig: true},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				actuator = NewActuator(mgr, ActuatorOptions{Profiles: profiles})
+				osc.Spec.Type = "almalinux"
+				osc.Spec.ProviderConfig = isolatedClusterProviderConfig
+
+				_, extensionUnits, extensionFiles, err := actuator.Reconcile(ctx, log, osc)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(extensionUnits).To(ConsistOf(HaveField("Name", "os-metal-pin-hosts.service")))
+				Expect(extensionFiles).To(ConsistOf(
+					HaveField("Path", "/etc/resolv.conf"),
+					HaveField("Path", "/etc/chrony.conf"),
+					HaveField("Path", "/usr/local/bin/os-metal-pin-hosts"),
+					HaveField("Path", "/etc/os-metal/hosts"),
+					HaveField("Path", "/etc/containerd/registries/ghcr.io/hosts.toml"),
+					HaveField("Path", "/etc/containerd/registries/quay.io/hosts.toml"),
+					HaveField("Path", "/etc/containerd/registries/docker.io/hosts.toml"),
+				))
+				Expect(extensionFiles[1].Content.Inline.Data).To(Equal(`# Generated by os-extension-metal
+server 134.60.1.27 iburst
+server 134.60.111.110 iburst
+driftfile /var/lib/chrony/drift
+makestep 1.0 3
+rtcsync
+`))
+			})
 		})
 	})
 
@@ -31,15 +31,21 @@ type AddOptions struct {
 	Controller controller.Options
 	// IgnoreOperationAnnotation specifies whether to ignore the operation annotation or not.
 	IgnoreOperationAnnotation bool
+	// Actuator are the ActuatorOptions.
+	Actuator ActuatorOptions
 }
 
 // AddToManagerWithOptions adds a controller with the given Options to the given manager.
 // The opts.Reconciler is being set with a newly instantiated actuator.
 func AddToManagerWithOptions(ctx context.Context, mgr manager.Manager, opts AddOptions) error {
+	if opts.Actuator.Profiles == nil {
+		opts.Actuator.Profiles = DefaultRegistry()
+	}
+
 	return operatingsystemconfig.Add(mgr, operatingsystemconfig.AddArgs{
-		Actuator:          NewActuator(mgr),
+		Actuator:          NewActuator(mgr, opts.Actuator),
 		Predicates:        operatingsystemconfig.DefaultPredicates(ctx, mgr, opts.IgnoreOperationAnnotation),
-		Types:             []string{"ubuntu", "debian", "nvidia"},
+		Types:             opts.Actuator.Profiles.Types(),
 		ControllerOptions: opts.Controller,
 	})
 }
@@ -0,0 +1,125 @@
+package operatingsystemconfig
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	extensionscontroller "github.com/gardener/gardener/extensions/pkg/controller"
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	v1beta1constants "github.com/gardener/gardener/pkg/apis/core/v1beta1/constants"
+	v1beta1helper "github.com/gardener/gardener/pkg/apis/core/v1beta1/helper"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// constraintK8sGreaterEqual131 matches the Kubernetes versions whose containerd config.toml is fully managed by the
+// gardener-node-agent and whose metal-images render the containerd default config.
+var constraintK8sGreaterEqual131 = mustConstraint(">= 1.31-0")
+
+// shootInfo contains the information about the shoot of an OperatingSystemConfig, which the renderers may depend on.
+// It is read from the extensions Cluster resource in the namespace of the OperatingSystemConfig.
+type shootInfo struct {
+	// kubernetesVersion is the Kubernetes version of the worker pool of the OperatingSystemConfig,
+	// which is the version of the control plane unless the worker pool overrides it.
+	kubernetesVersion *semver.Version
+	// workers are the worker pools of the shoot.
+	workers []gardencorev1beta1.Worker
+	// worker is the worker pool of the OperatingSystemConfig, nil if the OperatingSystemConfig does not belong to a worker pool of the shoot.
+	worker *gardencorev1beta1.Worker
+	// annotations are the annotations of the shoot.
+	annotations map[string]string
+	// creationTimestamp is the point in time the shoot was created.
+	creationTimestamp time.Time
+	// networks are the node, pod and service networks of the shoot.
+	networks []string
+}
+
+// getShootInfo returns the information about the shoot of the given OperatingSystemConfig.
+// If there is no Cluster resource for its namespace, e.g. while rendering without a cluster, nil is returned,
+// in which case the renderers behave as for a shoot of an unknown Kubernetes version.
+func (a *actuator) getShootInfo(ctx context.Context, osc *extensionsv1alpha1.OperatingSystemConfig) (*shootInfo, error) {
+	cluster, err := extensionscontroller.GetCluster(ctx, a.client, osc.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get cluster %q: %w", osc.Namespace, err)
+	}
+
+	return newShootInfo(cluster.Shoot, osc.Labels[v1beta1constants.LabelWorkerPool])
+}
+
+func newShootInfo(shoot *gardencorev1beta1.Shoot, workerPool string) (*shootInfo, error) {
+	if shoot == nil {
+		return nil, nil
+	}
+
+	info := &shootInfo{
+		workers:           shoot.Spec.Provider.Workers,
+		annotations:       shoot.Annotations,
+		creationTimestamp: shoot.CreationTimestamp.Time,
+	}
+
+	if n := shoot.Spec.Networking; n != nil {
+		for _, cidr := range []*string{n.Nodes, n.Pods, n.Services} {
+			if cidr != nil {
+				info.networks = append(info.networks, *cidr)
+			}
+		}
+	}
+
+	for i, w := range info.workers {
+		if w.Name == workerPool {
+			info.worker = &info.workers[i]
+			break
+		}
+	}
+
+	controlPlaneVersion, err := semver.NewVersion(shoot.Spec.Kubernetes.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse kubernetes version of shoot %q: %w", shoot.Name, err)
+	}
+
+	var workerKubernetes *gardencorev1beta1.WorkerKubernetes
+	if info.worker != nil {
+		workerKubernetes = info.worker.Kubernetes
+	}
+
+	info.kubernetesVersion, err = v1beta1helper.CalculateEffectiveKubernetesVersion(controlPlaneVersion, workerKubernetes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse kubernetes version of worker pool %q: %w", workerPool, err)
+	}
+
+	return info, nil
+}
+
+// kubernetesVersionMatches returns whether the Kubernetes version of the shoot is known and matches the given constraint.
+func (s *shootInfo) kubernetesVersionMatches(constraint *semver.Constraints) bool {
+	return s != nil && s.kubernetesVersion != nil && constraint.Check(s.kubernetesVersion)
+}
+
+// annotation returns the value of the given annotation of the shoot, an empty string if it is not set or the shoot is unknown.
+func (s *shootInfo) annotation(key string) string {
+	if s == nil {
+		return ""
+	}
+	return s.annotations[key]
+}
+
+// clusterNetworks returns the node, pod and service networks of the shoot, nil if the shoot is unknown.
+func (s *shootInfo) clusterNetworks() []string {
+	if s == nil {
+		return nil
+	}
+	return s.networks
+}
+
+func mustConstraint(constraint string) *semver.Constraints {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,110 @@
+package operatingsystemconfig
+
+import (
+	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("Cluster", func() {
+	var shoot *gardencorev1beta1.Shoot
+
+	BeforeEach(func() {
+		shoot = &gardencorev1beta1.Shoot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "foo",
+				Annotations: map[string]string{"foo": "bar"},
+			},
+			Spec: gardencorev1beta1.ShootSpec{
+				Kubernetes: gardencorev1beta1.Kubernetes{Version: "1.31.2"},
+				Provider: gardencorev1beta1.Provider{
+					Workers: []gardencorev1beta1.Worker{
+						{Name: "a"},
+						{Name: "b", Kubernetes: &gardencorev1beta1.WorkerKubernetes{Version: ptr.To("1.30.5")}},
+					},
+				},
+			},
+		}
+	})
+
+	Describe("#newShootInfo", func() {
+		It("should return the information of the worker pool", func() {
+			info, err := newShootInfo(shoot, "a")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info.kubernetesVersion.String()).To(Equal("1.31.2"))
+			Expect(info.workers).To(HaveLen(2))
+			Expect(info.worker).To(HaveField("Name", "a"))
+			Expect(info.annotations).To(Equal(map[string]string{"foo": "bar"}))
+		})
+
+		It("should return the kubernetes version of a worker pool overriding it", func() {
+			info, err := newShootInfo(shoot, "b")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info.kubernetesVersion.String()).To(Equal("1.30.5"))
+			Expect(info.worker).To(HaveField("Name", "b"))
+		})
+
+		It("should return the kubernetes version of the control plane for an unknown worker pool", func() {
+			info, err := newShootInfo(shoot, "c")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info.kubernetesVersion.String()).To(Equal("1.31.2"))
+			Expect(info.worker).To(BeNil())
+		})
+
+		It("should return the networks of the shoot", func() {
+			shoot.Spec.Networking = &gardencorev1beta1.Networking{
+				Nodes:    ptr.To("10.0.0.0/22"),
+				Pods:     ptr.To("10.240.0.0/13"),
+				Services: ptr.To("10.248.0.0/18"),
+			}
+
+			info, err := newShootInfo(shoot, "a")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info.clusterNetworks()).To(Equal([]string{"10.0.0.0/22", "10.240.0.0/13", "10.248.0.0/18"}))
+		})
+
+		It("should return no networks without networking", func() {
+			info, err := newShootInfo(shoot, "a")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info.clusterNetworks()).To(BeEmpty())
+			Expect((*shootInfo)(nil).clusterNetworks()).To(BeNil())
+		})
+
+		It("should return nil without a shoot", func() {
+			Expect(newShootInfo(nil, "a")).To(BeNil())
+		})
+
+		It("should fail for an invalid kubernetes version", func() {
+			shoot.Spec.Kubernetes.Version = "foo"
+
+			_, err := newShootInfo(shoot, "a")
+			Expect(err).To(MatchError(ContainSubstring(`unable to parse kubernetes version of shoot "foo"`)))
+		})
+	})
+
+	DescribeTable("#kubernetesVersionMatches",
+		func(version string, want bool) {
+			var info *shootInfo
+			if version != "" {
+				shoot.Spec.Kubernetes.Version = version
+
+				var err error
+				info, err = newShootInfo(shoot, "a")
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(info.kubernetesVersionMatches(constraintK8sGreaterEqual131)).To(Equal(want))
+		},
+		Entry("unknown shoot", "", false),
+		Entry("older version", "1.30.9", false),
+		Entry("same version", "1.31.0", true),
+		Entry("newer version", "1.32.1", true),
+	)
+})
@@ -0,0 +1,110 @@
+package operatingsystemconfig
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
+)
+
+// containerdConfig is the config.toml of containerd.
+type containerdConfig struct {
+	Version         int                     `toml:"version"`
+	Imports         []string                `toml:"imports"`
+	DisabledPlugins []string                `toml:"disabled_plugins"`
+	Plugins         containerdConfigPlugins `toml:"plugins"`
+}
+
+type containerdConfigPlugins struct {
+	CRI containerdCRIConfig `toml:"io.containerd.grpc.v1.cri"`
+}
+
+type containerdCRIConfig struct {
+	Registry containerdRegistryConfig `toml:"registry"`
+}
+
+type containerdRegistryConfig struct {
+	ConfigPath string `toml:"config_path"`
+}
+
+// newContainerdConfig returns the containerd config importing the drop-ins of /etc/containerd/conf.d,
+// which reads the registry configuration from the given path.
+func newContainerdConfig(registryConfigPath string) containerdConfig {
+	return containerdConfig{
+		Version:         2,
+		Imports:         []string{"/etc/containerd/conf.d/*.toml"},
+		DisabledPlugins: []string{},
+		Plugins: containerdConfigPlugins{
+			CRI: containerdCRIConfig{
+				Registry: containerdRegistryConfig{ConfigPath: registryConfigPath},
+			},
+		},
+	}
+}
+
+// legacyContainerdConfig is the layout of the containerd config rendered by previous versions, any change of the file
+// restarts containerd on the nodes.
+const legacyContainerdConfig = `# Generated by os-extension-metal
+version = 2
+imports = ["/etc/containerd/conf.d/*.toml"]
+disabled_plugins = []
+
+[plugins."io.containerd.grpc.v1.cri".registry]
+  config_path = %q
+`
+
+// encode encodes the containerd config, in the layout of previous versions unless the ContainerdConfigEncoder feature
+// gate is enabled. The registry config path of a profile is a clean absolute path, which is quoted the same in Go and TOML.
+func (c containerdConfig) encode() (string, error) {
+	if !features.DefaultFeatureGate.Enabled(features.ContainerdConfigEncoder) {
+		return fmt.Sprintf(legacyContainerdConfig, c.Plugins.CRI.Registry.ConfigPath), nil
+	}
+
+	data, err := encodeTOML(c)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode containerd config: %w", err)
+	}
+
+	return "# Generated by os-extension-metal\n" + data, nil
+}
+
+// containerdHosts is the hosts.toml of a registry.
+type containerdHosts struct {
+	Server string `toml:"server,omitempty"`
+	// Capabilities, CA, Client and SkipVerify configure the connection to the server, they are only required if the
+	// server is not the registry itself.
+	Capabilities []string    `toml:"capabilities,omitempty"`
+	CA           string      `toml:"ca,omitempty"`
+	Client       [][2]string `toml:"client,omitempty"`
+	SkipVerify   bool        `toml:"skip_verify,omitempty"`
+	// Hosts are the hosts by their endpoint. The encoder sorts them by their endpoint, which is the order containerd
+	// tries them in before the server.
+	Hosts map[string]containerdHost `toml:"host,omitempty"`
+}
+
+// containerdHost is a host of a hosts.toml.
+type containerdHost struct {
+	Capabilities []string    `toml:"capabilities"`
+	CA           string      `toml:"ca,omitempty"`
+	Client       [][2]string `toml:"client,omitempty"`
+	SkipVerify   bool        `toml:"skip_verify,omitempty"`
+}
+
+func (h containerdHosts) encode() (string, error) {
+	data, err := encodeTOML(h)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode containerd hosts: %w", err)
+	}
+
+	return data, nil
+}
+
+func encodeTOML(v any) (string, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,124 @@
+package operatingsystemconfig
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/gardener/gardener/pkg/utils/test"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Containerd", func() {
+	Describe("#containerdConfig", func() {
+		DescribeTable("should be parsed back into the same config",
+			func(encoder bool) {
+				DeferCleanup(test.WithFeatureGate(features.DefaultFeatureGate, features.ContainerdConfigEncoder, encoder))
+				config := newContainerdConfig("/etc/containerd/certs.d")
+
+				data, err := config.encode()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(data).To(HavePrefix("# Generated by os-extension-metal\n"))
+
+				var parsed containerdConfig
+				_, err = toml.Decode(data, &parsed)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(parsed).To(Equal(config))
+			},
+			Entry("previous layout", false),
+			Entry("encoder", true),
+		)
+
+		It("should keep the layout of previous versions unless the encoder is enabled", func() {
+			data, err := newContainerdConfig("/etc/containerd/certs.d").encode()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(Equal(`# Generated by os-extension-metal
+version = 2
+imports = ["/etc/containerd/conf.d/*.toml"]
+disabled_plugins = []
+
+[plugins."io.containerd.grpc.v1.cri".registry]
+  config_path = "/etc/containerd/certs.d"
+`))
+		})
+
+		It("should encode nested tables if the encoder is enabled", func() {
+			DeferCleanup(test.WithFeatureGate(features.DefaultFeatureGate, features.ContainerdConfigEncoder, true))
+
+			data, err := newContainerdConfig("/etc/containerd/registries").encode()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(Equal(`# Generated by os-extension-metal
+version = 2
+imports = ["/etc/containerd/conf.d/*.toml"]
+disabled_plugins = []
+
+[plugins]
+  [plugins."io.containerd.grpc.v1.cri"]
+    [plugins."io.containerd.grpc.v1.cri".registry]
+      config_path = "/etc/containerd/registries"
+`))
+		})
+	})
+
+	Describe("#containerdHosts", func() {
+		parse := func(data string) (containerdHosts, []string) {
+			var parsed containerdHosts
+			md, err := toml.Decode(data, &parsed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(md.Undecoded()).To(BeEmpty())
+
+			var order []string
+			for _, key := range md.Keys() {
+				if len(key) == 2 && key[0] == "host" {
+					order = append(order, key[1])
+				}
+			}
+
+			return parsed, order
+		}
+
+		It("should be parsed back into the same hosts sorted by their endpoint", func() {
+			hosts := containerdHosts{
+				Server: "https://registry-1.docker.io",
+				Hosts: map[string]containerdHost{
+					"https://b.metal-stack.dev": {Capabilities: []string{"pull", "resolve"}, SkipVerify: true},
+					"https://a.metal-stack.dev:8443": {
+						Capabilities: []string{"pull", "resolve"},
+						CA:           "/etc/containerd/certs.d/docker.io/a.metal-stack.dev_8443-ca.crt",
+						Client:       [][2]string{{"/etc/containerd/certs.d/docker.io/client.crt", "/etc/containerd/certs.d/docker.io/client.key"}},
+					},
+				},
+			}
+
+			data, err := hosts.encode()
+			Expect(err).NotTo(HaveOccurred())
+
+			parsed, order := parse(data)
+			Expect(parsed).To(Equal(hosts))
+			Expect(order).To(Equal([]string{"https://a.metal-stack.dev:8443", "https://b.metal-stack.dev"}))
+		})
+
+		It("should escape values which would otherwise inject configuration", func() {
+			endpoint := "https://a.metal-stack.dev\"]\n[host.\"https://evil"
+			hosts := containerdHosts{
+				Server: "https://evil\"\nskip_verify = true\n",
+				Hosts: map[string]containerdHost{
+					endpoint: {Capabilities: []string{"pull"}},
+				},
+			}
+
+			data, err := hosts.encode()
+			Expect(err).NotTo(HaveOccurred())
+
+			parsed, order := parse(data)
+			Expect(parsed).To(Equal(hosts))
+			Expect(order).To(Equal([]string{endpoint}))
+			Expect(parsed.SkipVerify).To(BeFalse())
+		})
+
+		It("should omit the server if it is empty", func() {
+			data, err := containerdHosts{}.encode()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(BeEmpty())
+		})
+	})
+})
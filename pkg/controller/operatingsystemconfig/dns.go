@@ -0,0 +1,110 @@
+package operatingsystemconfig
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// resolvConfPath is the path of the resolver configuration read by the libc resolver.
+	resolvConfPath = "/etc/resolv.conf"
+
+	// resolvConfLinkUnitName is the name of the unit pointing /etc/resolv.conf to systemd-resolved.
+	resolvConfLinkUnitName = "os-metal-resolv-conf-link.service"
+
+	resolvConfLinkUnit = `# Generated by os-extension-metal
+[Unit]
+Description=Point /etc/resolv.conf to systemd-resolved
+ConditionPathIsSymbolicLink=!/etc/resolv.conf
+Before=systemd-resolved.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/bin/ln -sf /run/systemd/resolve/stub-resolv.conf /etc/resolv.conf
+
+[Install]
+WantedBy=multi-user.target
+`
+)
+
+// additionalDNSConf returns the units and files configuring the given DNS servers for the resolver of the profile.
+func additionalDNSConf(p Profile, dnsServers []string) ([]extensionsv1alpha1.Unit, []extensionsv1alpha1.File) {
+	if len(dnsServers) == 0 {
+		return nil, nil
+	}
+
+	var (
+		units []extensionsv1alpha1.Unit
+		files []extensionsv1alpha1.File
+	)
+
+	if p.Resolver == ResolverSystemdResolved || p.Resolver == ResolverUnknown {
+		files = append(files, extensionsv1alpha1.File{
+			Path: "/etc/systemd/resolved.conf.d/dns.conf",
+			Content: extensionsv1alpha1.FileContent{
+				Inline: &extensionsv1alpha1.FileContentInline{
+					Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+					Data: fmt.Sprintf(`# Generated by os-extension-metal
+[Resolve]
+DNS=%s
+Domain=~.
+`, strings.Join(dnsServers, " ")),
+				},
+			},
+		})
+	}
+
+	if p.Resolver == ResolverSystemdResolved && p.LinkResolvConf {
+		units = append(units, extensionsv1alpha1.Unit{
+			Name:    resolvConfLinkUnitName,
+			Command: ptr.To(extensionsv1alpha1.CommandStart),
+			Enable:  ptr.To(true),
+			Content: ptr.To(resolvConfLinkUnit),
+		})
+	}
+
+	if p.Resolver == ResolverResolvConf || p.Resolver == ResolverUnknown {
+		resolvConf := "# Generated by os-extension-metal\n"
+		for _, ip := range dnsServers {
+			resolvConf += fmt.Sprintf("nameserver %s\n", ip)
+		}
+
+		files = append(files, extensionsv1alpha1.File{
+			Path: resolvConfPath,
+			Content: extensionsv1alpha1.FileContent{
+				Inline: &extensionsv1alpha1.FileContentInline{
+					Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+					Data:     resolvConf,
+				},
+			},
+		})
+	}
+
+	return units, files
+}
+
+// dnsProfile returns the profile the DNS configuration of the given OperatingSystemConfig is rendered for.
+//
+// Before the DNS configuration depended on the profile, /etc/resolv.conf was rendered for all images. The gardener-node-agent
+// removes files which are no longer part of the OperatingSystemConfig, which would remove the symlink of systemd-resolved or
+// the one created by the resolv.conf link unit and leave the node without resolver configuration. The nodes of an
+// OperatingSystemConfig which already got the file therefore keep getting the previous configuration until they are
+// replaced by nodes of a new OperatingSystemConfig.
+func dnsProfile(osc *extensionsv1alpha1.OperatingSystemConfig, p Profile) Profile {
+	if osc.Spec.Purpose != extensionsv1alpha1.OperatingSystemConfigPurposeReconcile || p.Resolver == ResolverUnknown {
+		return p
+	}
+	if !slices.ContainsFunc(osc.Status.ExtensionFiles, func(f extensionsv1alpha1.File) bool { return f.Path == resolvConfPath }) {
+		return p
+	}
+
+	p.Resolver = ResolverUnknown
+	p.LinkResolvConf = false
+
+	return p
+}
@@ -0,0 +1,84 @@
+package operatingsystemconfig
+
+import (
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DNS", func() {
+	var dnsServers = []string{"1.1.1.1", "1.0.0.1"}
+
+	paths := func(files []extensionsv1alpha1.File) []string {
+		var res []string
+		for _, f := range files {
+			res = append(res, f.Path)
+		}
+		return res
+	}
+
+	names := func(units []extensionsv1alpha1.Unit) []string {
+		var res []string
+		for _, u := range units {
+			res = append(res, u.Name)
+		}
+		return res
+	}
+
+	DescribeTable("#additionalDNSConf",
+		func(p Profile, wantUnits, wantFiles []string) {
+			units, files := additionalDNSConf(p, dnsServers)
+
+			Expect(names(units)).To(Equal(wantUnits))
+			Expect(paths(files)).To(Equal(wantFiles))
+		},
+		Entry("unknown resolver gets all configurations", Profile{},
+			nil, []string{"/etc/systemd/resolved.conf.d/dns.conf", "/etc/resolv.conf"}),
+		Entry("resolv.conf", Profile{Resolver: ResolverResolvConf},
+			nil, []string{"/etc/resolv.conf"}),
+		Entry("systemd-resolved", Profile{Resolver: ResolverSystemdResolved},
+			nil, []string{"/etc/systemd/resolved.conf.d/dns.conf"}),
+		Entry("systemd-resolved with resolv.conf link", Profile{Resolver: ResolverSystemdResolved, LinkResolvConf: true},
+			[]string{resolvConfLinkUnitName}, []string{"/etc/systemd/resolved.conf.d/dns.conf"}),
+		Entry("resolv.conf link is ignored without systemd-resolved", Profile{Resolver: ResolverResolvConf, LinkResolvConf: true},
+			nil, []string{"/etc/resolv.conf"}),
+	)
+
+	It("should not configure anything without dns servers", func() {
+		units, files := additionalDNSConf(Profile{Resolver: ResolverSystemdResolved, LinkResolvConf: true}, nil)
+		Expect(units).To(BeNil())
+		Expect(files).To(BeNil())
+	})
+
+	Describe("#dnsProfile", func() {
+		var (
+			osc     *extensionsv1alpha1.OperatingSystemConfig
+			profile = Profile{Type: "debian", Resolver: ResolverSystemdResolved, LinkResolvConf: true}
+		)
+
+		BeforeEach(func() {
+			osc = &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{Purpose: extensionsv1alpha1.OperatingSystemConfigPurposeReconcile},
+			}
+		})
+
+		It("should return the profile for nodes without the resolv.conf", func() {
+			osc.Status.ExtensionFiles = []extensionsv1alpha1.File{{Path: "/etc/systemd/resolved.conf.d/dns.conf"}}
+
+			Expect(dnsProfile(osc, profile)).To(Equal(profile))
+		})
+
+		It("should keep the resolv.conf for nodes which already got it", func() {
+			osc.Status.ExtensionFiles = []extensionsv1alpha1.File{{Path: "/etc/systemd/resolved.conf.d/dns.conf"}, {Path: "/etc/resolv.conf"}}
+
+			Expect(dnsProfile(osc, profile)).To(Equal(Profile{Type: "debian", Resolver: ResolverUnknown}))
+		})
+
+		It("should return the profile for the purpose provision", func() {
+			osc.Spec.Purpose = extensionsv1alpha1.OperatingSystemConfigPurposeProvision
+			osc.Status.ExtensionFiles = []extensionsv1alpha1.File{{Path: "/etc/resolv.conf"}}
+
+			Expect(dnsProfile(osc, profile)).To(Equal(profile))
+		})
+	})
+})
@@ -0,0 +1,220 @@
+package operatingsystemconfig
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	metalvalidation "github.com/metal-stack/os-metal-extension/pkg/apis/metal/validation"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// firewallRulesetPath contains the nftables ruleset enforcing the network isolation on the node.
+	firewallRulesetPath = "/etc/os-metal/nftables.conf"
+	// firewallUnitName is the name of the unit loading the nftables ruleset.
+	firewallUnitName = "os-metal-firewall.service"
+	// firewallTable is the name of the nftables table containing the rules of the node firewall.
+	firewallTable = "os_metal_isolation"
+
+	// firewallICMPTypes are the ICMP types allowed in both directions.
+	firewallICMPTypes = "echo-request, echo-reply, destination-unreachable, time-exceeded, parameter-problem"
+	// firewallICMPv6Types are the ICMPv6 types allowed in both directions, IPv6 does not work without the neighbor discovery.
+	firewallICMPv6Types = "echo-request, echo-reply, destination-unreachable, packet-too-big, time-exceeded, parameter-problem, " +
+		"nd-router-solicit, nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert, mld-listener-query, mld-listener-report, mld2-listener-report"
+
+	firewallUnit = `# Generated by os-extension-metal
+[Unit]
+Description=Enforce the network isolation with nftables
+Wants=network-pre.target
+Before=network-pre.target containerd.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/sbin/nft -f ` + firewallRulesetPath + `
+ExecStop=/usr/sbin/nft delete table inet ` + firewallTable + `
+
+[Install]
+WantedBy=multi-user.target
+`
+)
+
+// nodeFirewall returns the units and files loading an nftables ruleset, which only allows traffic from and to the
+// allowed networks, the DNS and NTP servers and the registry mirrors of the network isolation, the given networks of
+// the cluster and the traffic every node requires, and drops everything else.
+// Only the traffic of the node itself is filtered, forwarded traffic of the pods is left to the CNI.
+func nodeFirewall(networkIsolation *metal.NetworkIsolation, clusterNetworks []string) ([]extensionsv1alpha1.Unit, []extensionsv1alpha1.File) {
+	if !networkIsolation.NodeFirewall {
+		return nil, nil
+	}
+
+	units := []extensionsv1alpha1.Unit{
+		{
+			Name:      firewallUnitName,
+			Command:   ptr.To(extensionsv1alpha1.CommandStart),
+			Enable:    ptr.To(true),
+			Content:   ptr.To(firewallUnit),
+			FilePaths: []string{firewallRulesetPath},
+		},
+	}
+
+	files := []extensionsv1alpha1.File{
+		plainFile(firewallRulesetPath, 0644, firewallRuleset(networkIsolation, clusterNetworks)),
+	}
+
+	return units, files
+}
+
+// firewallRuleset renders the nftables ruleset of the node firewall.
+// The table is created and deleted before it is defined, such that loading the ruleset again replaces all rules atomically.
+//
+// Besides the configured networks and servers, every node requires:
+//   - ICMP and ICMPv6 including the neighbor and router discovery and multicast listener discovery
+//   - DHCP and DHCPv6
+//   - BGP with the leaf switches, which metal-stack machines peer with over IPv6 link-local addresses
+//   - all traffic from and to the node, pod and service networks of the cluster, e.g. for the kubelet and node ports
+func firewallRuleset(networkIsolation *metal.NetworkIsolation, clusterNetworks []string) string {
+	var input, output []string
+
+	clusterPrefixes := prefixes(clusterNetworks)
+	input = append(input, addrRules("saddr", clusterPrefixes, "")...)
+	output = append(output, addrRules("daddr", clusterPrefixes, "")...)
+
+	input = append(input, addrRules("saddr", prefixes(networkIsolation.AllowedNetworks.Ingress), "")...)
+	output = append(output, addrRules("daddr", prefixes(networkIsolation.AllowedNetworks.Egress), "")...)
+
+	dnsServers := prefixes(networkIsolation.DNSServers)
+	output = append(output, addrRules("daddr", dnsServers, "udp dport 53")...)
+	output = append(output, addrRules("daddr", dnsServers, "tcp dport 53")...)
+
+	// ntp servers may also be given as hostnames, which cannot be resolved at render time and are covered by the egress networks
+	output = append(output, addrRules("daddr", prefixes(networkIsolation.NTPServers), "udp dport 123")...)
+
+	mirrors := map[int32][]netip.Prefix{}
+	var ports []int32
+	for _, m := range networkIsolation.RegistryMirrors {
+		port := m.Port
+		if port == 0 {
+			u, err := url.Parse(m.Endpoint)
+			if err != nil {
+				continue
+			}
+			if port, err = metalvalidation.EndpointPort(u); err != nil {
+				continue
+			}
+		}
+
+		addrs := prefixes([]string{m.IP})
+		if len(addrs) == 0 {
+			continue
+		}
+
+		if _, ok := mirrors[port]; !ok {
+			ports = append(ports, port)
+		}
+		mirrors[port] = appendUnique(mirrors[port], addrs...)
+	}
+	for _, port := range ports {
+		output = append(output, addrRules("daddr", mirrors[port], fmt.Sprintf("tcp dport %d", port))...)
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `# Generated by os-extension-metal
+table inet %[1]s
+delete table inet %[1]s
+
+table inet %[1]s {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		iif "lo" accept
+		ct state established,related accept
+		icmp type { %[2]s } accept
+		icmpv6 type { %[3]s } accept
+		udp sport 67 udp dport 68 accept
+		ip6 saddr fe80::/10 udp sport 547 udp dport 546 accept
+		ip6 saddr fe80::/10 tcp dport 179 accept
+`, firewallTable, firewallICMPTypes, firewallICMPv6Types)
+	for _, r := range input {
+		fmt.Fprintf(&b, "\t\t%s\n", r)
+	}
+	fmt.Fprintf(&b, `	}
+
+	chain output {
+		type filter hook output priority filter; policy drop;
+		oif "lo" accept
+		ct state established,related accept
+		icmp type { %[1]s } accept
+		icmpv6 type { %[2]s } accept
+		udp sport 68 udp dport 67 accept
+		udp sport 546 udp dport 547 accept
+		ip6 daddr fe80::/10 tcp dport 179 accept
+`, firewallICMPTypes, firewallICMPv6Types)
+	for _, r := range output {
+		fmt.Fprintf(&b, "\t\t%s\n", r)
+	}
+	b.WriteString("\t}\n}\n")
+
+	return b.String()
+}
+
+// addrRules returns an accepting rule per address family for the given prefixes, restricted by the given match.
+func addrRules(direction string, prefixes []netip.Prefix, match string) []string {
+	var v4, v6 []string
+	for _, p := range prefixes {
+		addr := p.String()
+		if p.IsSingleIP() {
+			addr = p.Addr().String()
+		}
+
+		if p.Addr().Is4() {
+			v4 = append(v4, addr)
+		} else {
+			v6 = append(v6, addr)
+		}
+	}
+
+	var rules []string
+	for family, addrs := range [][]string{v4, v6} {
+		if len(addrs) == 0 {
+			continue
+		}
+
+		rule := fmt.Sprintf("%s %s { %s }", []string{"ip", "ip6"}[family], direction, strings.Join(addrs, ", "))
+		if match != "" {
+			rule += " " + match
+		}
+		rules = append(rules, rule+" accept")
+	}
+
+	return rules
+}
+
+// prefixes parses the given CIDRs and IPs into prefixes, invalid values and hostnames are skipped.
+func prefixes(values []string) []netip.Prefix {
+	var res []netip.Prefix
+	for _, v := range values {
+		if p, err := netip.ParsePrefix(v); err == nil {
+			res = appendUnique(res, p.Masked())
+			continue
+		}
+		if a, err := netip.ParseAddr(v); err == nil {
+			res = appendUnique(res, netip.PrefixFrom(a, a.BitLen()))
+		}
+	}
+	return res
+}
+
+func appendUnique(prefixes []netip.Prefix, add ...netip.Prefix) []netip.Prefix {
+	for _, p := range add {
+		if !slices.Contains(prefixes, p) {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
@@ -0,0 +1,137 @@
+package operatingsystemconfig
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Firewall", func() {
+	Describe("#nodeFirewall", func() {
+		It("should not render anything if the node firewall is disabled", func() {
+			units, files := nodeFirewall(&metal.NetworkIsolation{
+				AllowedNetworks: metal.AllowedNetworks{Egress: []string{"10.0.0.0/8"}},
+			}, nil)
+
+			Expect(units).To(BeNil())
+			Expect(files).To(BeNil())
+		})
+
+		It("should allow the configured networks and servers", func() {
+			units, files := nodeFirewall(&metal.NetworkIsolation{
+				NodeFirewall: true,
+				AllowedNetworks: metal.AllowedNetworks{
+					Ingress: []string{"10.0.0.0/8", "2001:db8::/32"},
+					Egress:  []string{"10.0.0.0/8", "100.64.0.1/10"},
+				},
+				DNSServers: []string{"1.1.1.1", "1.0.0.1"},
+				NTPServers: []string{"134.60.1.27", "ntp.metal-stack.dev", "2001:db8::123"},
+				RegistryMirrors: []metal.RegistryMirror{
+					{Name: "a", Endpoint: "https://a.metal-stack.dev", IP: "1.2.3.4", Port: 443},
+					{Name: "b", Endpoint: "http://b.metal-stack.dev:8080", IP: "1.2.3.5"},
+					{Name: "c", Endpoint: "https://c.metal-stack.dev", IP: "1.2.3.4"},
+					{Name: "d", Endpoint: "https://d.metal-stack.dev"},
+				},
+			}, nil)
+
+			Expect(units).To(ConsistOf(HaveField("Name", firewallUnitName)))
+			Expect(units[0].FilePaths).To(Equal([]string{firewallRulesetPath}))
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Path).To(Equal(firewallRulesetPath))
+			Expect(files[0].Content.Inline.Data).To(Equal(`# Generated by os-extension-metal
+table inet os_metal_isolation
+delete table inet os_metal_isolation
+
+table inet os_metal_isolation {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		iif "lo" accept
+		ct state established,related accept
+		icmp type { echo-request, echo-reply, destination-unreachable, time-exceeded, parameter-problem } accept
+		icmpv6 type { echo-request, echo-reply, destination-unreachable, packet-too-big, time-exceeded, parameter-problem, nd-router-solicit, nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert, mld-listener-query, mld-listener-report, mld2-listener-report } accept
+		udp sport 67 udp dport 68 accept
+		ip6 saddr fe80::/10 udp sport 547 udp dport 546 accept
+		ip6 saddr fe80::/10 tcp dport 179 accept
+		ip saddr { 10.0.0.0/8 } accept
+		ip6 saddr { 2001:db8::/32 } accept
+	}
+
+	chain output {
+		type filter hook output priority filter; policy drop;
+		oif "lo" accept
+		ct state established,related accept
+		icmp type { echo-request, echo-reply, destination-unreachable, time-exceeded, parameter-problem } accept
+		icmpv6 type { echo-request, echo-reply, destination-unreachable, packet-too-big, time-exceeded, parameter-problem, nd-router-solicit, nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert, mld-listener-query, mld-listener-report, mld2-listener-report } accept
+		udp sport 68 udp dport 67 accept
+		udp sport 546 udp dport 547 accept
+		ip6 daddr fe80::/10 tcp dport 179 accept
+		ip daddr { 10.0.0.0/8, 100.64.0.0/10 } accept
+		ip daddr { 1.1.1.1, 1.0.0.1 } udp dport 53 accept
+		ip daddr { 1.1.1.1, 1.0.0.1 } tcp dport 53 accept
+		ip daddr { 134.60.1.27 } udp dport 123 accept
+		ip6 daddr { 2001:db8::123 } udp dport 123 accept
+		ip daddr { 1.2.3.4 } tcp dport 443 accept
+		ip daddr { 1.2.3.5 } tcp dport 8080 accept
+	}
+}
+`))
+		})
+
+		It("should allow the networks of the cluster", func() {
+			_, files := nodeFirewall(&metal.NetworkIsolation{
+				NodeFirewall: true,
+				AllowedNetworks: metal.AllowedNetworks{
+					Egress: []string{"10.0.0.0/8"},
+				},
+			}, []string{"10.0.0.0/22", "10.240.0.0/12", "2001:db8:1::/112"})
+
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Content.Inline.Data).To(ContainSubstring(`		ip6 saddr fe80::/10 tcp dport 179 accept
+		ip saddr { 10.0.0.0/22, 10.240.0.0/12 } accept
+		ip6 saddr { 2001:db8:1::/112 } accept
+	}
+`))
+			Expect(files[0].Content.Inline.Data).To(ContainSubstring(`		ip6 daddr fe80::/10 tcp dport 179 accept
+		ip daddr { 10.0.0.0/22, 10.240.0.0/12 } accept
+		ip6 daddr { 2001:db8:1::/112 } accept
+		ip daddr { 10.0.0.0/8 } accept
+	}
+`))
+		})
+	})
+
+	Describe("#firewallRuleset", func() {
+		It("should be accepted by nft", func() {
+			nft, err := exec.LookPath("nft")
+			if err != nil {
+				Skip("nft is not installed")
+			}
+
+			path := filepath.Join(GinkgoT().TempDir(), "ruleset.nft")
+			Expect(os.WriteFile(path, []byte(firewallRuleset(&metal.NetworkIsolation{
+				NodeFirewall: true,
+				AllowedNetworks: metal.AllowedNetworks{
+					Ingress: []string{"10.0.0.0/8", "2001:db8::/32"},
+					Egress:  []string{"10.0.0.0/8", "100.64.0.1/10"},
+				},
+				DNSServers: []string{"1.1.1.1", "2001:4860:4860::8888"},
+				NTPServers: []string{"134.60.1.27", "2001:db8::123"},
+				RegistryMirrors: []metal.RegistryMirror{
+					{Name: "a", Endpoint: "https://a.metal-stack.dev", IP: "1.2.3.4", Port: 443},
+					{Name: "b", Endpoint: "http://b.metal-stack.dev:8080", IP: "1.2.3.5"},
+				},
+			}, []string{"10.0.0.0/22", "10.240.0.0/12", "2001:db8:1::/112"})), 0600)).To(Succeed())
+
+			out, err := exec.Command(nft, "--check", "--file", path).CombinedOutput()
+			if err != nil && strings.Contains(string(out), "Operation not permitted") {
+				Skip("nft is not permitted to check the ruleset")
+			}
+			Expect(err).NotTo(HaveOccurred(), string(out))
+		})
+	})
+})
@@ -0,0 +1,98 @@
+package ignition
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/apis/extensions/v1alpha1/helper"
+	"github.com/vincent-petithory/dataurl"
+	"k8s.io/utils/ptr"
+)
+
+const compressionGzip = "gzip"
+
+// fileContent is the content of a file as it is embedded into the ignition config.
+type fileContent struct {
+	// data is the decoded content of the file.
+	data []byte
+	// source is the data url of the content, it is only set for compressed contents.
+	source string
+	// compression is the compression of the source, if any.
+	compression string
+}
+
+// contentEncoder decodes the file contents of an OperatingSystemConfig and compresses them if configured.
+// It keeps track of the sizes of the compressed contents, such that the savings can be reported.
+type contentEncoder struct {
+	compressionThreshold int
+
+	compressedFiles  int
+	uncompressedSize int
+	compressedSize   int
+}
+
+func newContentEncoder(opts Options) *contentEncoder {
+	return &contentEncoder{
+		compressionThreshold: opts.CompressionThreshold,
+	}
+}
+
+// encode returns the content of the given file. Contents reaching the compression threshold are compressed with gzip
+// and embedded as base64 data url as long as this reduces their size and the file is not marked to be transmitted unencoded.
+func (e *contentEncoder) encode(f extensionsv1alpha1.File) (fileContent, error) {
+	if f.Content.Inline == nil {
+		return fileContent{}, nil
+	}
+
+	data, err := helper.Decode(f.Content.Inline.Encoding, []byte(f.Content.Inline.Data))
+	if err != nil {
+		return fileContent{}, fmt.Errorf("unable to decode content from osc: %w", err)
+	}
+
+	if e.compressionThreshold <= 0 || len(data) < e.compressionThreshold || ptr.Deref(f.Content.TransmitUnencoded, false) {
+		return fileContent{data: data}, nil
+	}
+
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return fileContent{}, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fileContent{}, fmt.Errorf("unable to compress content of file %q: %w", f.Path, err)
+	}
+	if err := w.Close(); err != nil {
+		return fileContent{}, fmt.Errorf("unable to compress content of file %q: %w", f.Path, err)
+	}
+
+	var (
+		plain      = dataURL(data)
+		compressed = "data:;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	)
+
+	if len(compressed) >= len(plain) {
+		return fileContent{data: data}, nil
+	}
+
+	e.compressedFiles++
+	e.uncompressedSize += len(plain)
+	e.compressedSize += len(compressed)
+
+	return fileContent{
+		data:        data,
+		source:      compressed,
+		compression: compressionGzip,
+	}, nil
+}
+
+// dataURL returns the given contents as a data url in the same way as the container-linux-config-transpiler does.
+func dataURL(contents []byte) string {
+	return (&url.URL{
+		Scheme: "data",
+		Opaque: "," + dataurl.Escape(contents),
+	}).String()
+}
@@ -0,0 +1,213 @@
+package ignition
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+)
+
+func Test_contentEncoder_encode(t *testing.T) {
+	var (
+		large  = strings.Repeat("a large and very compressible file content\n", 100)
+		random = make([]byte, 2048)
+	)
+	_, _ = rand.Read(random)
+
+	tests := []struct {
+		name            string
+		threshold       int
+		file            extensionsv1alpha1.File
+		wantCompression bool
+		wantData        []byte
+		wantErr         bool
+	}{
+		{
+			name:      "file without inline content",
+			threshold: 1,
+			file:      extensionsv1alpha1.File{Path: "/etc/foo"},
+		},
+		{
+			name:      "compression disabled",
+			threshold: 0,
+			file:      inlineFile("/etc/foo", large, nil),
+			wantData:  []byte(large),
+		},
+		{
+			name:      "content below threshold",
+			threshold: len(large) + 1,
+			file:      inlineFile("/etc/foo", large, nil),
+			wantData:  []byte(large),
+		},
+		{
+			name:      "content transmitted unencoded",
+			threshold: 1,
+			file:      inlineFile("/etc/foo", large, ptr.To(true)),
+			wantData:  []byte(large),
+		},
+		{
+			name:      "content growing through compression",
+			threshold: 1,
+			file:      inlineFile("/etc/foo", "foo", nil),
+			wantData:  []byte("foo"),
+		},
+		{
+			name:      "binary content",
+			threshold: 1,
+			file: extensionsv1alpha1.File{
+				Path: "/etc/foo",
+				Content: extensionsv1alpha1.FileContent{
+					Inline: &extensionsv1alpha1.FileContentInline{
+						Encoding: string(extensionsv1alpha1.B64FileCodecID),
+						Data:     base64.StdEncoding.EncodeToString(random),
+					},
+				},
+			},
+			wantCompression: true,
+			wantData:        random,
+		},
+		{
+			name:            "compressed content",
+			threshold:       len(large),
+			file:            inlineFile("/etc/foo", large, ptr.To(false)),
+			wantCompression: true,
+			wantData:        []byte(large),
+		},
+		{
+			name:      "invalid encoding",
+			threshold: 1,
+			file: extensionsv1alpha1.File{
+				Path: "/etc/foo",
+				Content: extensionsv1alpha1.FileContent{
+					Inline: &extensionsv1alpha1.FileContentInline{
+						Encoding: "unknown",
+						Data:     "foo",
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := newContentEncoder(Options{CompressionThreshold: tt.threshold})
+
+			got, err := enc.encode(tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("contentEncoder.encode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if diff := cmp.Diff(got.data, tt.wantData); diff != "" {
+				t.Errorf("contentEncoder.encode() data diff = %s", diff)
+			}
+
+			if !tt.wantCompression {
+				if got.compression != "" || got.source != "" || enc.compressedFiles != 0 {
+					t.Errorf("contentEncoder.encode() compressed content unexpectedly")
+				}
+				return
+			}
+
+			if got.compression != compressionGzip {
+				t.Fatalf("contentEncoder.encode() compression = %q, want %q", got.compression, compressionGzip)
+			}
+			if enc.compressedFiles != 1 || enc.compressedSize != len(got.source) || enc.uncompressedSize != len(dataURL(tt.wantData)) {
+				t.Errorf("contentEncoder.encode() unexpected compression stats: %+v", enc)
+			}
+
+			compressed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(got.source, "data:;base64,"))
+			if err != nil {
+				t.Fatalf("source is not base64 encoded: %v", err)
+			}
+			r, err := gzip.NewReader(bytes.NewReader(compressed))
+			if err != nil {
+				t.Fatalf("source is not gzip compressed: %v", err)
+			}
+			decompressed, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("source is not gzip compressed: %v", err)
+			}
+			if diff := cmp.Diff(decompressed, tt.wantData); diff != "" {
+				t.Errorf("decompressed source diff = %s", diff)
+			}
+		})
+	}
+}
+
+func TestTranspileCompressed(t *testing.T) {
+	osc := &extensionsv1alpha1.OperatingSystemConfig{
+		Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+			Files: []extensionsv1alpha1.File{
+				inlineFile("/etc/large", strings.Repeat("a", 1024), nil),
+				inlineFile("/etc/unencoded", strings.Repeat("b", 1024), ptr.To(true)),
+				inlineFile("/etc/small", "c", nil),
+			},
+		},
+	}
+
+	tests := []struct {
+		version SpecVersion
+		want    []string
+	}{
+		{
+			version: SpecVersionV2,
+			want: []string{
+				`{"filesystem":"root","overwrite":true,"path":"/etc/large","contents":{"compression":"gzip","source":"data:;base64,`,
+				`{"filesystem":"root","overwrite":true,"path":"/etc/unencoded","contents":{"source":"data:,bbbb`,
+				`{"filesystem":"root","overwrite":true,"path":"/etc/small","contents":{"source":"data:,c"`,
+			},
+		},
+		{
+			version: SpecVersionV3,
+			want: []string{
+				`"path":"/etc/large","user":{},"contents":{"compression":"gzip","source":"data:;base64,`,
+				`"path":"/etc/unencoded","user":{},"contents":{"source":"data:,bbbb`,
+				`"path":"/etc/small","user":{},"contents":{"source":"data:,c"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.version), func(t *testing.T) {
+			tr, err := New(logr.Discard(), Options{Version: tt.version, CompressionThreshold: 512})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, _, err := tr.Transpile(osc)
+			if err != nil {
+				t.Fatalf("Transpile() error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("Transpile() = %s, does not contain %s", string(got), want)
+				}
+			}
+		})
+	}
+}
+
+func inlineFile(path, data string, transmitUnencoded *bool) extensionsv1alpha1.File {
+	return extensionsv1alpha1.File{
+		Path: path,
+		Content: extensionsv1alpha1.FileContent{
+			Inline: &extensionsv1alpha1.FileContentInline{
+				Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+				Data:     data,
+			},
+			TransmitUnencoded: transmitUnencoded,
+		},
+	}
+}
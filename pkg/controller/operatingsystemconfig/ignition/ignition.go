@@ -3,48 +3,198 @@ package ignition
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/flatcar/container-linux-config-transpiler/config/types"
+	"github.com/flatcar/ignition/config/validate/report"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
-	"github.com/gardener/gardener/pkg/apis/extensions/v1alpha1/helper"
 	"github.com/go-logr/logr"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
 	"k8s.io/utils/ptr"
 )
 
-type ignition struct {
-	log logr.Logger
+// SpecVersion is the ignition config specification version of the rendered userdata.
+type SpecVersion string
+
+const (
+	// SpecVersionV2 renders a Container Linux Config and transpiles it with the container-linux-config-transpiler
+	// into an ignition v2 config, which is understood by ignition 0.x as contained in existing metal-images.
+	SpecVersionV2 SpecVersion = "2.3.0"
+	// SpecVersionV3 maps the OperatingSystemConfig directly onto the ignition v3 config types,
+	// which requires at least ignition 2.14 in the metal-image.
+	SpecVersionV3 SpecVersion = "3.4.0"
+
+	// DefaultSpecVersion is the spec version used as long as no other version is configured.
+	DefaultSpecVersion = SpecVersionV2
+
+	// DefaultRegistryConfigPath is the directory containing the containerd registry host configurations of the metal-images.
+	DefaultRegistryConfigPath = "/etc/containerd/certs.d"
+)
+
+// SupportedSpecVersions returns all spec versions a Transpiler can be created for.
+func SupportedSpecVersions() []SpecVersion {
+	return []SpecVersion{SpecVersionV2, SpecVersionV3}
+}
+
+// Transpiler transpiles an OperatingSystemConfig into ignition userdata.
+type Transpiler interface {
+	// Transpile transpiles the OSC into an ignition script.
+	// Warnings of the transpilation are returned, which are turned into an error in strict mode, informational findings are ignored.
+	Transpile(osc *extensionsv1alpha1.OperatingSystemConfig) ([]byte, []string, error)
+}
+
+// Options configure the rendering of the ignition config.
+type Options struct {
+	// Version is the ignition spec version to render, defaults to DefaultSpecVersion.
+	Version SpecVersion
+	// CompressionThreshold is the minimum size in bytes of a file's content to be compressed with gzip.
+	// Compression is disabled if it is zero, files which must be transmitted unencoded are never compressed.
+	CompressionThreshold int
+	// Strict fails the transpilation if the resulting ignition config has any warnings.
+	Strict bool
+	// RegistryConfigPath is the directory containing the containerd registry host configurations, which is used to pull
+	// the images of files referencing a container image, defaults to DefaultRegistryConfigPath.
+	RegistryConfigPath string
+}
+
+func (o Options) registryConfigPath() string {
+	if o.RegistryConfigPath == "" {
+		return DefaultRegistryConfigPath
+	}
+	return o.RegistryConfigPath
+}
+
+// New creates a new Transpiler rendering the configured ignition spec version.
+func New(log logr.Logger, opts Options) (Transpiler, error) {
+	switch opts.Version {
+	case "", SpecVersionV2:
+		return &ignition{log: log, opts: opts}, nil
+	case SpecVersionV3:
+		return &native{log: log, opts: opts}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ignition spec version %q, supported versions are %v", opts.Version, SupportedSpecVersions())
+	}
+}
+
+// ValidateSpecVersion returns an error if no Transpiler can be created for the given spec version.
+func ValidateSpecVersion(version SpecVersion) error {
+	_, err := New(logr.Discard(), Options{Version: version})
+	return err
+}
+
+// logCompression reports the savings of the compressed file contents.
+func logCompression(log logr.Logger, enc *contentEncoder) {
+	if enc.compressedFiles == 0 {
+		return
+	}
+
+	log.Info("Compressed file contents of userdata", "files", enc.compressedFiles, "sizeBefore", enc.uncompressedSize, "sizeAfter", enc.compressedSize)
+}
+
+// checkWarnings returns an error for the given warnings in strict mode.
+func checkWarnings(opts Options, warnings []string) error {
+	if !opts.Strict || len(warnings) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("ignition config has warnings in strict mode: %s", strings.Join(warnings, "; "))
+}
+
+// unitEnabled returns the enablement ignition should apply to the given unit.
+// Ignition has no notion of starting a unit, units are started on boot through their enablement.
+// Therefore a unit is enabled if it should be started and disabled if it should be stopped, whereas an
+// explicit Enable takes precedence over the start and restart commands.
+// Units without content only carry drop-ins for a unit shipped with the image, their enablement is left
+// to the image unless specified otherwise. A restart of such a unit is not required as the drop-ins are
+// written before the unit is started for the first time.
+func unitEnabled(u extensionsv1alpha1.Unit) *bool {
+	if ptr.Deref(u.Command, "") == extensionsv1alpha1.CommandStop {
+		return ptr.To(false)
+	}
+
+	if u.Enable != nil {
+		return ptr.To(*u.Enable)
+	}
+
+	if u.Content == nil && ptr.Deref(u.Command, "") != extensionsv1alpha1.CommandStart {
+		return nil
+	}
+
+	return ptr.To(true)
 }
 
-// New creates a new IgnitionGenerator.
-func New(log logr.Logger) *ignition {
-	return &ignition{
-		log: log,
+// sortedOutput returns the OSC with its units sorted by name and its files sorted by path if the
+// IgnitionSortedOutput feature gate is enabled. The sort is stable, such that files with the same path keep their order.
+func sortedOutput(osc *extensionsv1alpha1.OperatingSystemConfig) *extensionsv1alpha1.OperatingSystemConfig {
+	if !features.DefaultFeatureGate.Enabled(features.IgnitionSortedOutput) {
+		return osc
 	}
+
+	osc = osc.DeepCopy()
+	slices.SortStableFunc(osc.Spec.Units, func(a, b extensionsv1alpha1.Unit) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	slices.SortStableFunc(osc.Spec.Files, func(a, b extensionsv1alpha1.File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return osc
+}
+
+type ignition struct {
+	log  logr.Logger
+	opts Options
 }
 
 // Transpile transpiles the OSC into an ignition script.
-func (t *ignition) Transpile(osc *extensionsv1alpha1.OperatingSystemConfig) ([]byte, error) {
-	data, err := ignitionFromOperatingSystemConfig(osc)
+func (t *ignition) Transpile(osc *extensionsv1alpha1.OperatingSystemConfig) ([]byte, []string, error) {
+	enc := newContentEncoder(t.opts)
+
+	data, err := ignitionFromOperatingSystemConfig(expandImageRefs(osc, t.opts.registryConfigPath()), enc)
 	if err != nil {
-		return nil, fmt.Errorf("unable to map osc into ignition config: %w", err)
+		return nil, nil, fmt.Errorf("unable to map osc into ignition config: %w", err)
 	}
 
-	out, report := types.Convert(data, "", nil)
-	if report.IsFatal() {
-		return nil, fmt.Errorf("could not transpile ignition config: %s", report.String())
+	out, r := types.Convert(data, "", nil)
+	if r.IsFatal() {
+		return nil, nil, fmt.Errorf("could not transpile ignition config: %s", r.String())
 	}
 
-	return json.Marshal(out)
+	warnings := transpilerWarnings(r)
+	if err := checkWarnings(t.opts, warnings); err != nil {
+		return nil, warnings, err
+	}
+
+	logCompression(t.log, enc)
+
+	userData, err := json.Marshal(out)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	return userData, warnings, nil
+}
+
+// transpilerWarnings returns the warnings of the given transpiler report, informational and deprecation entries are ignored.
+func transpilerWarnings(r report.Report) []string {
+	var warnings []string
+	for _, e := range r.Entries {
+		if e.Kind == report.EntryWarning {
+			warnings = append(warnings, e.String())
+		}
+	}
+	return warnings
 }
 
 // ignitionFromOperatingSystemConfig is responsible to transpile the gardener OperatingSystemConfig to a ignition configuration.
-// This is currently done with container-linux-config-transpile v0.9.0 and creates ignition v2.2.0 compatible configuration,
-// which is used by ignition 0.32.0.
-// TODO
-// Starting with ignition 2.0, ignition itself contains the required parsing logic, so we can use ignition directly.
-// see https://github.com/coreos/ignition/blob/master/config/config.go#L38
-// Therefore we must update ignition to 2.0.0 in the images and transform the gardener config to the ignition config types instead.
-func ignitionFromOperatingSystemConfig(osc *extensionsv1alpha1.OperatingSystemConfig) (types.Config, error) {
+// This is done with container-linux-config-transpile v0.9.0 and creates ignition v2.3.0 compatible configuration,
+// which is used by ignition 0.x.
+// Images containing ignition 2.x should be provisioned with the native renderer instead, see nativeFromOperatingSystemConfig.
+// Files referencing a container image must already be expanded, see expandImageRefs.
+func ignitionFromOperatingSystemConfig(osc *extensionsv1alpha1.OperatingSystemConfig, enc *contentEncoder) (types.Config, error) {
+	osc = sortedOutput(osc)
+
 	cfg := types.Config{}
 
 	cfg.Systemd = types.Systemd{}
@@ -53,7 +203,7 @@ func ignitionFromOperatingSystemConfig(osc *extensionsv1alpha1.OperatingSystemCo
 		unit := types.SystemdUnit{
 			Contents: ptr.Deref(u.Content, ""),
 			Name:     u.Name,
-			Enabled:  ptr.To(true),
+			Enabled:  unitEnabled(u),
 		}
 		for _, dr := range u.DropIns {
 			unit.Dropins = append(unit.Dropins, types.SystemdUnitDropIn{
@@ -79,13 +229,18 @@ func ignitionFromOperatingSystemConfig(osc *extensionsv1alpha1.OperatingSystemCo
 			Overwrite:  ptr.To(true),
 		}
 
-		if f.Content.Inline != nil {
-			inline, err := helper.Decode(f.Content.Inline.Encoding, []byte(f.Content.Inline.Data))
-			if err != nil {
-				return types.Config{}, fmt.Errorf("unable to decode content from osc: %w", err)
-			}
+		content, err := enc.encode(f)
+		if err != nil {
+			return types.Config{}, err
+		}
 
-			ignitionFile.Contents.Inline = string(inline)
+		if content.compression != "" {
+			ignitionFile.Contents.Remote = types.Remote{
+				Url:         content.source,
+				Compression: content.compression,
+			}
+		} else {
+			ignitionFile.Contents.Inline = string(content.data)
 		}
 
 		cfg.Storage.Files = append(cfg.Storage.Files, ignitionFile)
@@ -4,9 +4,12 @@ import (
 	"testing"
 
 	"github.com/flatcar/container-linux-config-transpiler/config/types"
+	"github.com/flatcar/ignition/config/validate/report"
 	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/utils/test"
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
 	"k8s.io/utils/ptr"
 )
 
@@ -43,6 +46,52 @@ func TestIgnitionFromOperatingSystemConfig(t *testing.T) {
 			},
 		},
 
+		{
+			name: "disabled service and drop-ins for a service shipped with the image",
+			config: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{
+							Name:    "foo.service",
+							Content: ptr.To("[Service]\nExecStart=/bin/foo"),
+							Enable:  ptr.To(false),
+						},
+						{
+							Name:    "containerd.service",
+							Command: ptr.To(extensionsv1alpha1.CommandRestart),
+							DropIns: []extensionsv1alpha1.DropIn{
+								{
+									Name:    "10-env.conf",
+									Content: "[Service]\nEnvironment=A=B",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+			want: types.Config{
+				Systemd: types.Systemd{
+					Units: []types.SystemdUnit{
+						{
+							Name:     "foo.service",
+							Contents: "[Service]\nExecStart=/bin/foo",
+							Enabled:  ptr.To(false),
+						},
+						{
+							Name: "containerd.service",
+							Dropins: []types.SystemdUnitDropIn{
+								{
+									Name:     "10-env.conf",
+									Contents: "[Service]\nEnvironment=A=B",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
 		{
 			name: "simple files",
 			config: &extensionsv1alpha1.OperatingSystemConfig{
@@ -119,7 +168,7 @@ func TestIgnitionFromOperatingSystemConfig(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ignitionFromOperatingSystemConfig(tt.config)
+			got, err := ignitionFromOperatingSystemConfig(tt.config, &contentEncoder{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -132,12 +181,113 @@ func TestIgnitionFromOperatingSystemConfig(t *testing.T) {
 	}
 }
 
-func Test_ignition_Transpile(t *testing.T) {
+func Test_unitEnabled(t *testing.T) {
 	tests := []struct {
 		name    string
-		osc     *extensionsv1alpha1.OperatingSystemConfig
-		want    string
-		wantErr bool
+		content *string
+		enable  *bool
+		command *extensionsv1alpha1.UnitCommand
+		want    *bool
+	}{
+		{name: "unit, enable unset, command unset", content: ptr.To("[Service]"), enable: nil, command: nil, want: ptr.To(true)},
+		{name: "unit, enable unset, command start", content: ptr.To("[Service]"), enable: nil, command: ptr.To(extensionsv1alpha1.CommandStart), want: ptr.To(true)},
+		{name: "unit, enable unset, command restart", content: ptr.To("[Service]"), enable: nil, command: ptr.To(extensionsv1alpha1.CommandRestart), want: ptr.To(true)},
+		{name: "unit, enable unset, command stop", content: ptr.To("[Service]"), enable: nil, command: ptr.To(extensionsv1alpha1.CommandStop), want: ptr.To(false)},
+		{name: "unit, enable true, command unset", content: ptr.To("[Service]"), enable: ptr.To(true), command: nil, want: ptr.To(true)},
+		{name: "unit, enable true, command start", content: ptr.To("[Service]"), enable: ptr.To(true), command: ptr.To(extensionsv1alpha1.CommandStart), want: ptr.To(true)},
+		{name: "unit, enable true, command restart", content: ptr.To("[Service]"), enable: ptr.To(true), command: ptr.To(extensionsv1alpha1.CommandRestart), want: ptr.To(true)},
+		{name: "unit, enable true, command stop", content: ptr.To("[Service]"), enable: ptr.To(true), command: ptr.To(extensionsv1alpha1.CommandStop), want: ptr.To(false)},
+		{name: "unit, enable false, command unset", content: ptr.To("[Service]"), enable: ptr.To(false), command: nil, want: ptr.To(false)},
+		{name: "unit, enable false, command start", content: ptr.To("[Service]"), enable: ptr.To(false), command: ptr.To(extensionsv1alpha1.CommandStart), want: ptr.To(false)},
+		{name: "unit, enable false, command restart", content: ptr.To("[Service]"), enable: ptr.To(false), command: ptr.To(extensionsv1alpha1.CommandRestart), want: ptr.To(false)},
+		{name: "unit, enable false, command stop", content: ptr.To("[Service]"), enable: ptr.To(false), command: ptr.To(extensionsv1alpha1.CommandStop), want: ptr.To(false)},
+		{name: "drop-in only, enable unset, command unset", content: nil, enable: nil, command: nil, want: nil},
+		{name: "drop-in only, enable unset, command start", content: nil, enable: nil, command: ptr.To(extensionsv1alpha1.CommandStart), want: ptr.To(true)},
+		{name: "drop-in only, enable unset, command restart", content: nil, enable: nil, command: ptr.To(extensionsv1alpha1.CommandRestart), want: nil},
+		{name: "drop-in only, enable unset, command stop", content: nil, enable: nil, command: ptr.To(extensionsv1alpha1.CommandStop), want: ptr.To(false)},
+		{name: "drop-in only, enable true, command unset", content: nil, enable: ptr.To(true), command: nil, want: ptr.To(true)},
+		{name: "drop-in only, enable true, command start", content: nil, enable: ptr.To(true), command: ptr.To(extensionsv1alpha1.CommandStart), want: ptr.To(true)},
+		{name: "drop-in only, enable true, command restart", content: nil, enable: ptr.To(true), command: ptr.To(extensionsv1alpha1.CommandRestart), want: ptr.To(true)},
+		{name: "drop-in only, enable true, command stop", content: nil, enable: ptr.To(true), command: ptr.To(extensionsv1alpha1.CommandStop), want: ptr.To(false)},
+		{name: "drop-in only, enable false, command unset", content: nil, enable: ptr.To(false), command: nil, want: ptr.To(false)},
+		{name: "drop-in only, enable false, command start", content: nil, enable: ptr.To(false), command: ptr.To(extensionsv1alpha1.CommandStart), want: ptr.To(false)},
+		{name: "drop-in only, enable false, command restart", content: nil, enable: ptr.To(false), command: ptr.To(extensionsv1alpha1.CommandRestart), want: ptr.To(false)},
+		{name: "drop-in only, enable false, command stop", content: nil, enable: ptr.To(false), command: ptr.To(extensionsv1alpha1.CommandStop), want: ptr.To(false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unitEnabled(extensionsv1alpha1.Unit{
+				Name:    "foo.service",
+				Content: tt.content,
+				Enable:  tt.enable,
+				Command: tt.command,
+			})
+
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("unitEnabled() diff = %s", diff)
+			}
+		})
+	}
+}
+
+func Test_sortedOutput(t *testing.T) {
+	osc := &extensionsv1alpha1.OperatingSystemConfig{
+		Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+			Units: []extensionsv1alpha1.Unit{{Name: "kubelet.service"}, {Name: "containerd.service"}},
+			Files: []extensionsv1alpha1.File{{Path: "/var/lib/b", Permissions: ptr.To(int32(0600))}, {Path: "/etc/a"}, {Path: "/var/lib/b"}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		wantUnits []string
+		wantFiles []extensionsv1alpha1.File
+	}{
+		{
+			name:      "feature gate disabled keeps the order",
+			enabled:   false,
+			wantUnits: []string{"kubelet.service", "containerd.service"},
+			wantFiles: []extensionsv1alpha1.File{{Path: "/var/lib/b", Permissions: ptr.To(int32(0600))}, {Path: "/etc/a"}, {Path: "/var/lib/b"}},
+		},
+		{
+			name:      "feature gate enabled sorts units and files",
+			enabled:   true,
+			wantUnits: []string{"containerd.service", "kubelet.service"},
+			wantFiles: []extensionsv1alpha1.File{{Path: "/etc/a"}, {Path: "/var/lib/b", Permissions: ptr.To(int32(0600))}, {Path: "/var/lib/b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer test.WithFeatureGate(features.DefaultFeatureGate, features.IgnitionSortedOutput, tt.enabled)()
+
+			got := sortedOutput(osc)
+
+			var gotUnits []string
+			for _, u := range got.Spec.Units {
+				gotUnits = append(gotUnits, u.Name)
+			}
+			if diff := cmp.Diff(gotUnits, tt.wantUnits); diff != "" {
+				t.Errorf("units diff: %s", diff)
+			}
+			if diff := cmp.Diff(got.Spec.Files, tt.wantFiles); diff != "" {
+				t.Errorf("files diff: %s", diff)
+			}
+			if osc.Spec.Units[0].Name != "kubelet.service" {
+				t.Errorf("sortedOutput() modified the given osc")
+			}
+		})
+	}
+}
+
+func Test_ignition_Transpile(t *testing.T) {
+	tests := []struct {
+		name         string
+		osc          *extensionsv1alpha1.OperatingSystemConfig
+		strict       bool
+		want         string
+		wantWarnings []string
+		wantErr      bool
 	}{
 		{
 			name: "transpiles to ignition format 2.3.0",
@@ -152,20 +302,68 @@ func Test_ignition_Transpile(t *testing.T) {
 			},
 			want: `{"ignition":{"config":{},"security":{"tls":{}},"timeouts":{},"version":"2.3.0"},"networkd":{},"passwd":{},"storage":{"files":[{"filesystem":"root","overwrite":true,"path":"/etc/a","contents":{"source":"data:,","verification":{}},"mode":420}]},"systemd":{}}`,
 		},
+		{
+			name: "warnings are returned",
+			osc: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{
+							Name:    "foo.service",
+							Content: ptr.To("[Service]\nExecStart=/bin/true"),
+						},
+					},
+				},
+			},
+			want:         `{"ignition":{"config":{},"security":{"tls":{}},"timeouts":{},"version":"2.3.0"},"networkd":{},"passwd":{},"storage":{},"systemd":{"units":[{"contents":"[Service]\nExecStart=/bin/true","enabled":true,"name":"foo.service"}]}}`,
+			wantWarnings: []string{`warning: unit "foo.service" is enabled, but has no install section so enable does nothing`},
+		},
+		{
+			name: "warnings are rejected in strict mode",
+			osc: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{
+							Name:    "foo.service",
+							Content: ptr.To("[Service]\nExecStart=/bin/true"),
+						},
+					},
+				},
+			},
+			strict:       true,
+			wantWarnings: []string{`warning: unit "foo.service" is enabled, but has no install section so enable does nothing`},
+			wantErr:      true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tr := &ignition{
-				log: logr.Discard(),
+				log:  logr.Discard(),
+				opts: Options{Strict: tt.strict},
 			}
-			got, err := tr.Transpile(tt.osc)
+			got, warnings, err := tr.Transpile(tt.osc)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ignition.Transpile() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if diff := cmp.Diff(warnings, tt.wantWarnings); diff != "" {
+				t.Errorf("ignition.Transpile() warnings diff = %s", diff)
+			}
 			if diff := cmp.Diff(string(got), tt.want); diff != "" {
 				t.Errorf("ignition.Transpile() diff = %s", diff)
 			}
 		})
 	}
 }
+
+func Test_transpilerWarnings(t *testing.T) {
+	r := report.Report{Entries: []report.Entry{
+		{Kind: report.EntryError, Message: "an error"},
+		{Kind: report.EntryWarning, Message: "a warning"},
+		{Kind: report.EntryInfo, Message: "an info"},
+		{Kind: report.EntryDeprecated, Message: "a deprecation"},
+	}}
+
+	if diff := cmp.Diff(transpilerWarnings(r), []string{"warning: a warning"}); diff != "" {
+		t.Errorf("transpilerWarnings() diff = %s", diff)
+	}
+}
@@ -0,0 +1,175 @@
+package ignition
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// ExtractFromImageScriptPath is the path of the helper script extracting files from container images on the machine.
+	ExtractFromImageScriptPath = "/usr/local/bin/os-metal-extract-from-image"
+
+	extractFromImageUnitPrefix   = "os-metal-extract-"
+	extractFromImageDropInName   = "10-os-metal-image-files.conf"
+	extractFromImageNamespace    = "os-metal"
+	extractFromImageDefaultPerms = int32(0644)
+)
+
+// extractFromImageScript returns the helper script extracting files from container images, which pulls the images
+// with the registry host configurations of the given directory, such that the registry mirrors are used.
+func extractFromImageScript(registryConfigPath string) string {
+	return `#!/bin/bash
+# Generated by os-extension-metal
+set -o errexit
+set -o nounset
+set -o pipefail
+
+image="$1"
+path_in_image="$2"
+destination="$3"
+permissions="$4"
+
+mount_dir="$(mktemp -d)"
+cleanup() {
+  ctr --namespace ` + extractFromImageNamespace + ` images unmount "$mount_dir" >/dev/null 2>&1 || true
+  rmdir "$mount_dir"
+}
+trap cleanup EXIT
+
+# retried by the script, as Restart= is only supported for oneshot units since systemd 244
+until ctr --namespace ` + extractFromImageNamespace + ` images pull --hosts-dir "` + registryConfigPath + `" "$image"; do
+  echo "unable to pull $image, retrying in 10s" >&2
+  sleep 10
+done
+ctr --namespace ` + extractFromImageNamespace + ` images mount "$image" "$mount_dir"
+
+mkdir -p "$(dirname "$destination")"
+cp "$mount_dir/$path_in_image" "$destination.tmp"
+chmod "$permissions" "$destination.tmp"
+mv "$destination.tmp" "$destination"
+`
+}
+
+var unitNameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// expandImageRefs returns a copy of the given OperatingSystemConfig in which every file with content from a container image
+// is replaced by a oneshot unit, which extracts the file from the image with ctr on first boot. Units depending on such a
+// file are ordered after the extraction. Ignition itself is not able to fetch files from container images.
+// The images are pulled with the registry host configurations of the given directory.
+func expandImageRefs(osc *extensionsv1alpha1.OperatingSystemConfig, registryConfigPath string) *extensionsv1alpha1.OperatingSystemConfig {
+	if !slices.ContainsFunc(osc.Spec.Files, func(f extensionsv1alpha1.File) bool { return f.Content.ImageRef != nil }) {
+		return osc
+	}
+
+	osc = osc.DeepCopy()
+
+	var (
+		files          []extensionsv1alpha1.File
+		units          []extensionsv1alpha1.Unit
+		unitByFilePath = map[string]string{}
+	)
+
+	for _, f := range osc.Spec.Files {
+		if f.Content.ImageRef == nil {
+			files = append(files, f)
+			continue
+		}
+
+		unit := extractFromImageUnit(f)
+		unitByFilePath[f.Path] = unit.Name
+		units = append(units, unit)
+	}
+
+	files = append(files, extensionsv1alpha1.File{
+		Path:        ExtractFromImageScriptPath,
+		Permissions: ptr.To(int32(0755)),
+		Content: extensionsv1alpha1.FileContent{
+			Inline: &extensionsv1alpha1.FileContentInline{
+				Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+				Data:     extractFromImageScript(registryConfigPath),
+			},
+		},
+	})
+
+	for i, u := range osc.Spec.Units {
+		var dependencies []string
+		for _, p := range u.FilePaths {
+			if name, ok := unitByFilePath[p]; ok && !slices.Contains(dependencies, name) {
+				dependencies = append(dependencies, name)
+			}
+		}
+
+		if len(dependencies) == 0 {
+			continue
+		}
+
+		osc.Spec.Units[i].DropIns = append(osc.Spec.Units[i].DropIns, extensionsv1alpha1.DropIn{
+			Name: extractFromImageDropInName,
+			Content: fmt.Sprintf(`# Generated by os-extension-metal
+[Unit]
+Requires=%[1]s
+After=%[1]s
+`, strings.Join(dependencies, " ")),
+		})
+	}
+
+	osc.Spec.Files = files
+	osc.Spec.Units = append(units, osc.Spec.Units...)
+
+	return osc
+}
+
+// extractFromImageUnit returns the oneshot unit extracting the given file from its container image.
+// The unit only runs as long as the file does not exist, i.e. on first boot. Failed pulls are retried by the script
+// until the registry is reachable.
+func extractFromImageUnit(f extensionsv1alpha1.File) extensionsv1alpha1.Unit {
+	var (
+		ref       = f.Content.ImageRef
+		perms     = fmt.Sprintf("%04o", ptr.Deref(f.Permissions, extractFromImageDefaultPerms))
+		execStart = strings.Join([]string{
+			ExtractFromImageScriptPath,
+			strconv.Quote(ref.Image),
+			strconv.Quote(ref.FilePathInImage),
+			strconv.Quote(f.Path),
+			perms,
+		}, " ")
+	)
+
+	return extensionsv1alpha1.Unit{
+		Name:    extractFromImageUnitName(f.Path),
+		Command: ptr.To(extensionsv1alpha1.CommandStart),
+		Enable:  ptr.To(true),
+		Content: ptr.To(fmt.Sprintf(`# Generated by os-extension-metal
+[Unit]
+Description=Extract %[1]s from image %[2]s
+Wants=network-online.target containerd.service
+After=network-online.target containerd.service
+ConditionPathExists=!%[1]s
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=%[3]s
+
+[Install]
+WantedBy=multi-user.target
+`, f.Path, ref.Image, execStart)),
+	}
+}
+
+// extractFromImageUnitName returns a unit name for the given file path, which is readable and unique.
+func extractFromImageUnitName(filePath string) string {
+	hash := sha256.Sum256([]byte(filePath))
+	base := unitNameSanitizer.ReplaceAllString(path.Base(filePath), "_")
+
+	return extractFromImageUnitPrefix + base + "-" + hex.EncodeToString(hash[:])[:8] + ".service"
+}
@@ -0,0 +1,164 @@
+package ignition
+
+import (
+	"strings"
+	"testing"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+)
+
+func Test_expandImageRefs(t *testing.T) {
+	extractScript := extensionsv1alpha1.File{
+		Path:        ExtractFromImageScriptPath,
+		Permissions: ptr.To(int32(0755)),
+		Content: extensionsv1alpha1.FileContent{
+			Inline: &extensionsv1alpha1.FileContentInline{
+				Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+				Data:     extractFromImageScript(DefaultRegistryConfigPath),
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		osc  *extensionsv1alpha1.OperatingSystemConfig
+		want *extensionsv1alpha1.OperatingSystemConfig
+	}{
+		{
+			name: "without image references the osc stays untouched",
+			osc: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{{Name: "foo.service", FilePaths: []string{"/etc/foo"}}},
+					Files: []extensionsv1alpha1.File{{Path: "/etc/foo", Content: extensionsv1alpha1.FileContent{Inline: &extensionsv1alpha1.FileContentInline{Data: "foo"}}}},
+				},
+			},
+			want: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{{Name: "foo.service", FilePaths: []string{"/etc/foo"}}},
+					Files: []extensionsv1alpha1.File{{Path: "/etc/foo", Content: extensionsv1alpha1.FileContent{Inline: &extensionsv1alpha1.FileContentInline{Data: "foo"}}}},
+				},
+			},
+		},
+		{
+			name: "image references are extracted by units",
+			osc: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{Name: "kubelet.service", Content: ptr.To("[Service]"), FilePaths: []string{"/opt/bin/kubelet", "/etc/foo", "/opt/bin/kubelet"}},
+						{Name: "foo.service", Content: ptr.To("[Service]"), FilePaths: []string{"/etc/foo"}},
+					},
+					Files: []extensionsv1alpha1.File{
+						{
+							Path:        "/opt/bin/kubelet",
+							Permissions: ptr.To(int32(0755)),
+							Content: extensionsv1alpha1.FileContent{
+								ImageRef: &extensionsv1alpha1.FileContentImageRef{
+									Image:           "registry.k8s.io/hyperkube:v1.31.1",
+									FilePathInImage: "/kubelet",
+								},
+							},
+						},
+						{Path: "/etc/foo", Content: extensionsv1alpha1.FileContent{Inline: &extensionsv1alpha1.FileContentInline{Data: "foo"}}},
+					},
+				},
+			},
+			want: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{
+							Name:    "os-metal-extract-kubelet-e70bb6e8.service",
+							Command: ptr.To(extensionsv1alpha1.CommandStart),
+							Enable:  ptr.To(true),
+							Content: ptr.To(`# Generated by os-extension-metal
+[Unit]
+Description=Extract /opt/bin/kubelet from image registry.k8s.io/hyperkube:v1.31.1
+Wants=network-online.target containerd.service
+After=network-online.target containerd.service
+ConditionPathExists=!/opt/bin/kubelet
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/usr/local/bin/os-metal-extract-from-image "registry.k8s.io/hyperkube:v1.31.1" "/kubelet" "/opt/bin/kubelet" 0755
+
+[Install]
+WantedBy=multi-user.target
+`),
+						},
+						{
+							Name:      "kubelet.service",
+							Content:   ptr.To("[Service]"),
+							FilePaths: []string{"/opt/bin/kubelet", "/etc/foo", "/opt/bin/kubelet"},
+							DropIns: []extensionsv1alpha1.DropIn{
+								{
+									Name: "10-os-metal-image-files.conf",
+									Content: `# Generated by os-extension-metal
+[Unit]
+Requires=os-metal-extract-kubelet-e70bb6e8.service
+After=os-metal-extract-kubelet-e70bb6e8.service
+`,
+								},
+							},
+						},
+						{Name: "foo.service", Content: ptr.To("[Service]"), FilePaths: []string{"/etc/foo"}},
+					},
+					Files: []extensionsv1alpha1.File{
+						{Path: "/etc/foo", Content: extensionsv1alpha1.FileContent{Inline: &extensionsv1alpha1.FileContentInline{Data: "foo"}}},
+						extractScript,
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.osc.DeepCopy()
+
+			got := expandImageRefs(tt.osc, DefaultRegistryConfigPath)
+
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("expandImageRefs() diff = %s", diff)
+			}
+			if diff := cmp.Diff(tt.osc, original); diff != "" {
+				t.Errorf("expandImageRefs() modified the given osc: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_extractFromImageScript(t *testing.T) {
+	tests := []struct {
+		registryConfigPath string
+		want               string
+	}{
+		{registryConfigPath: DefaultRegistryConfigPath, want: `until ctr --namespace os-metal images pull --hosts-dir "/etc/containerd/certs.d" "$image"; do`},
+		{registryConfigPath: "/etc/containerd/registries", want: `until ctr --namespace os-metal images pull --hosts-dir "/etc/containerd/registries" "$image"; do`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.registryConfigPath, func(t *testing.T) {
+			if got := extractFromImageScript(tt.registryConfigPath); !strings.Contains(got, tt.want) {
+				t.Errorf("extractFromImageScript() = %v, want it to contain %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_extractFromImageUnitName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/opt/bin/kubelet", want: "os-metal-extract-kubelet-e70bb6e8.service"},
+		{path: "/usr/bin/kubelet", want: "os-metal-extract-kubelet-856939be.service"},
+		{path: "/etc/some file@1", want: "os-metal-extract-some_file_1-555646bb.service"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := extractFromImageUnitName(tt.path); got != tt.want {
+				t.Errorf("extractFromImageUnitName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
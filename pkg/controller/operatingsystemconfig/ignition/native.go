@@ -0,0 +1,120 @@
+package ignition
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/coreos/ignition/v2/config/v3_4/types"
+	"github.com/coreos/ignition/v2/config/validate"
+	"github.com/coreos/vcontext/report"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/utils/ptr"
+)
+
+type native struct {
+	log  logr.Logger
+	opts Options
+}
+
+// Transpile transpiles the OSC into an ignition script.
+func (t *native) Transpile(osc *extensionsv1alpha1.OperatingSystemConfig) ([]byte, []string, error) {
+	enc := newContentEncoder(t.opts)
+
+	cfg, err := nativeFromOperatingSystemConfig(expandImageRefs(osc, t.opts.registryConfigPath()), enc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to map osc into ignition config: %w", err)
+	}
+
+	r := validate.ValidateWithContext(cfg, nil)
+	if r.IsFatal() {
+		return nil, nil, fmt.Errorf("invalid ignition config: %s", r.String())
+	}
+
+	warnings := validationWarnings(r)
+	if err := checkWarnings(t.opts, warnings); err != nil {
+		return nil, warnings, err
+	}
+
+	logCompression(t.log, enc)
+
+	userData, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	return userData, warnings, nil
+}
+
+// validationWarnings returns the warnings of the given validation report, informational entries are ignored.
+func validationWarnings(r report.Report) []string {
+	var warnings []string
+	for _, e := range r.Entries {
+		if e.Kind == report.Warn {
+			warnings = append(warnings, e.String())
+		}
+	}
+	return warnings
+}
+
+// nativeFromOperatingSystemConfig maps the gardener OperatingSystemConfig onto the ignition v3 config types,
+// such that the result can be consumed by ignition 2.x without any further transpilation.
+// Files referencing a container image must already be expanded, see expandImageRefs.
+func nativeFromOperatingSystemConfig(osc *extensionsv1alpha1.OperatingSystemConfig, enc *contentEncoder) (types.Config, error) {
+	osc = sortedOutput(osc)
+
+	cfg := types.Config{
+		Ignition: types.Ignition{
+			Version: string(SpecVersionV3),
+		},
+	}
+
+	for _, u := range osc.Spec.Units {
+		unit := types.Unit{
+			Contents: u.Content,
+			Name:     u.Name,
+			Enabled:  unitEnabled(u),
+		}
+		for _, dr := range u.DropIns {
+			unit.Dropins = append(unit.Dropins, types.Dropin{
+				Name:     dr.Name,
+				Contents: ptr.To(dr.Content),
+			})
+		}
+		cfg.Systemd.Units = append(cfg.Systemd.Units, unit)
+	}
+
+	for _, f := range osc.Spec.Files {
+		var mode *int
+		if f.Permissions != nil {
+			m := int(*f.Permissions)
+			mode = &m
+		}
+
+		ignitionFile := types.File{
+			Node: types.Node{
+				Path:      f.Path,
+				Overwrite: ptr.To(true),
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Mode: mode,
+			},
+		}
+
+		content, err := enc.encode(f)
+		if err != nil {
+			return types.Config{}, err
+		}
+
+		if content.compression != "" {
+			ignitionFile.Contents.Source = ptr.To(content.source)
+			ignitionFile.Contents.Compression = ptr.To(content.compression)
+		} else {
+			ignitionFile.Contents.Source = ptr.To(dataURL(content.data))
+		}
+
+		cfg.Storage.Files = append(cfg.Storage.Files, ignitionFile)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,376 @@
+package ignition
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/ignition/v2/config/v3_4"
+	"github.com/coreos/ignition/v2/config/v3_4/types"
+	"github.com/coreos/vcontext/report"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+)
+
+func TestNativeFromOperatingSystemConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *extensionsv1alpha1.OperatingSystemConfig
+		want    types.Config
+		wantErr bool
+	}{
+		{
+			name: "simple service",
+			config: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{
+							Name:    "kubelet.service",
+							Content: ptr.To("[Unit]\nDescription=kubelet\n[Install]\nWantedBy=multi-user.target\n[Service]\nExecStart=/bin/kubelet"),
+							DropIns: []extensionsv1alpha1.DropIn{
+								{
+									Name:    "10-env.conf",
+									Content: "[Service]\nEnvironment=A=B",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+			want: types.Config{
+				Ignition: types.Ignition{
+					Version: "3.4.0",
+				},
+				Systemd: types.Systemd{
+					Units: []types.Unit{
+						{
+							Name:     "kubelet.service",
+							Contents: ptr.To("[Unit]\nDescription=kubelet\n[Install]\nWantedBy=multi-user.target\n[Service]\nExecStart=/bin/kubelet"),
+							Enabled:  ptr.To(true),
+							Dropins: []types.Dropin{
+								{
+									Name:     "10-env.conf",
+									Contents: ptr.To("[Service]\nEnvironment=A=B"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "stopped service and drop-ins for a service shipped with the image",
+			config: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{
+							Name:    "foo.service",
+							Content: ptr.To("[Service]\nExecStart=/bin/foo"),
+							Enable:  ptr.To(true),
+							Command: ptr.To(extensionsv1alpha1.CommandStop),
+						},
+						{
+							Name: "containerd.service",
+							DropIns: []extensionsv1alpha1.DropIn{
+								{
+									Name:    "10-env.conf",
+									Content: "[Service]\nEnvironment=A=B",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+			want: types.Config{
+				Ignition: types.Ignition{
+					Version: "3.4.0",
+				},
+				Systemd: types.Systemd{
+					Units: []types.Unit{
+						{
+							Name:     "foo.service",
+							Contents: ptr.To("[Service]\nExecStart=/bin/foo"),
+							Enabled:  ptr.To(false),
+						},
+						{
+							Name: "containerd.service",
+							Dropins: []types.Dropin{
+								{
+									Name:     "10-env.conf",
+									Contents: ptr.To("[Service]\nEnvironment=A=B"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "simple files",
+			config: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Files: []extensionsv1alpha1.File{
+						{
+							Path: "/etc/hostname",
+							Content: extensionsv1alpha1.FileContent{
+								Inline: &extensionsv1alpha1.FileContentInline{
+									Data: "testhost",
+								},
+							},
+							Permissions: ptr.To(int32(0644)),
+						},
+						{
+							Path: "/etc/bar",
+							Content: extensionsv1alpha1.FileContent{
+								Inline: &extensionsv1alpha1.FileContentInline{
+									Data:     "YmFyIGJheg==",
+									Encoding: string(extensionsv1alpha1.B64FileCodecID),
+								},
+							},
+							Permissions: ptr.To(int32(0744)),
+						},
+						{
+							Path: "/etc/empty",
+						},
+					},
+				},
+			},
+			wantErr: false,
+			want: types.Config{
+				Ignition: types.Ignition{
+					Version: "3.4.0",
+				},
+				Storage: types.Storage{
+					Files: []types.File{
+						{
+							Node: types.Node{
+								Path:      "/etc/hostname",
+								Overwrite: ptr.To(true),
+							},
+							FileEmbedded1: types.FileEmbedded1{
+								Contents: types.Resource{
+									Source: ptr.To("data:,testhost"),
+								},
+								Mode: ptr.To(0644),
+							},
+						},
+						{
+							Node: types.Node{
+								Path:      "/etc/bar",
+								Overwrite: ptr.To(true),
+							},
+							FileEmbedded1: types.FileEmbedded1{
+								Contents: types.Resource{
+									Source: ptr.To("data:,bar%20baz"),
+								},
+								Mode: ptr.To(0744),
+							},
+						},
+						{
+							Node: types.Node{
+								Path:      "/etc/empty",
+								Overwrite: ptr.To(true),
+							},
+							FileEmbedded1: types.FileEmbedded1{
+								Contents: types.Resource{
+									Source: ptr.To("data:,"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid encoding",
+			config: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Files: []extensionsv1alpha1.File{
+						{
+							Path: "/etc/foo",
+							Content: extensionsv1alpha1.FileContent{
+								Inline: &extensionsv1alpha1.FileContentInline{
+									Data:     "foo",
+									Encoding: "unknown",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nativeFromOperatingSystemConfig(tt.config, &contentEncoder{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_native_Transpile(t *testing.T) {
+	tests := []struct {
+		name         string
+		osc          *extensionsv1alpha1.OperatingSystemConfig
+		strict       bool
+		want         string
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{
+			name: "transpiles to ignition format 3.4.0",
+			osc: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Files: []extensionsv1alpha1.File{
+						{
+							Path: "/etc/a",
+						},
+					},
+				},
+			},
+			want: `{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.4.0"},"kernelArguments":{},"passwd":{},"storage":{"files":[{"group":{},"overwrite":true,"path":"/etc/a","user":{},"contents":{"source":"data:,","verification":{}}}]},"systemd":{}}`,
+		},
+		{
+			name: "duplicate file paths are rejected",
+			osc: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Files: []extensionsv1alpha1.File{
+						{
+							Path: "/etc/a",
+						},
+						{
+							Path: "/etc/a",
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "warnings are returned",
+			osc: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{
+							Name:    "foo.service",
+							Content: ptr.To("[Service]\nExecStart=/bin/true"),
+						},
+					},
+				},
+			},
+			want:         `{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.4.0"},"kernelArguments":{},"passwd":{},"storage":{},"systemd":{"units":[{"contents":"[Service]\nExecStart=/bin/true","enabled":true,"name":"foo.service"}]}}`,
+			wantWarnings: []string{`warning at $.systemd.units.0.contents: unit "foo.service" is enabled, but has no install section so enable does nothing`},
+		},
+		{
+			name: "warnings are rejected in strict mode",
+			osc: &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+					Units: []extensionsv1alpha1.Unit{
+						{
+							Name:    "foo.service",
+							Content: ptr.To("[Service]\nExecStart=/bin/true"),
+						},
+					},
+				},
+			},
+			strict:       true,
+			wantWarnings: []string{`warning at $.systemd.units.0.contents: unit "foo.service" is enabled, but has no install section so enable does nothing`},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &native{
+				log:  logr.Discard(),
+				opts: Options{Strict: tt.strict},
+			}
+			got, warnings, err := tr.Transpile(tt.osc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("native.Transpile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(warnings, tt.wantWarnings); diff != "" {
+				t.Errorf("native.Transpile() warnings diff = %s", diff)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(string(got), tt.want); diff != "" {
+				t.Errorf("native.Transpile() diff = %s", diff)
+			}
+
+			// the rendered config must be parsable by ignition itself
+			if _, report, err := v3_4.Parse(got); err != nil {
+				t.Errorf("ignition is unable to parse the rendered config: %v: %s", err, report.String())
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		version SpecVersion
+		want    Transpiler
+		wantErr bool
+	}{
+		{
+			name:    "v2",
+			version: SpecVersionV2,
+			want:    &ignition{log: logr.Discard()},
+		},
+		{
+			name:    "v3",
+			version: SpecVersionV3,
+			want:    &native{log: logr.Discard()},
+		},
+		{
+			name:    "default",
+			version: "",
+			want:    &ignition{log: logr.Discard()},
+		},
+		{
+			name:    "unsupported version",
+			version: "3.6.0-experimental",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(logr.Discard(), Options{Version: tt.version})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+				t.Errorf("New() = %T, want %T", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validationWarnings(t *testing.T) {
+	r := report.Report{Entries: []report.Entry{
+		{Kind: report.Error, Message: "an error"},
+		{Kind: report.Warn, Message: "a warning"},
+		{Kind: report.Info, Message: "an info"},
+	}}
+
+	if diff := cmp.Diff(validationWarnings(r), []string{"warning: a warning"}); diff != "" {
+		t.Errorf("validationWarnings() diff = %s", diff)
+	}
+}
@@ -0,0 +1,80 @@
+package operatingsystemconfig
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
+)
+
+// AnnotationLegacyDNSNTPFiles is the annotation of a shoot to explicitly render ("true") or stop rendering ("false")
+// the legacy DNS and NTP files for its worker nodes, regardless of the cutoff.
+const AnnotationLegacyDNSNTPFiles = "os-metal.extensions.gardener.cloud/legacy-dns-ntp-files"
+
+// legacyDNSNTPFilePaths are the paths of all legacy DNS and NTP files rendered for any profile.
+var legacyDNSNTPFilePaths = []string{
+	"/etc/systemd/resolved.conf.d/dns.conf",
+	resolvConfPath,
+	"/etc/systemd/timesyncd.conf",
+	"/etc/chrony.conf",
+}
+
+// legacyDNSNTPFilesPolicy returns whether the legacy DNS and NTP files are rendered for the given OperatingSystemConfig
+// of the given shoot and why.
+//
+// The files are only required for worker machines of isolated clusters created before metal-stack configured DNS and
+// NTP on the machines, otherwise they lose connectivity when the gardener-node-agent cleans up the definitions,
+// see https://github.com/metal-stack/gardener-extension-provider-metal/issues/433.
+// They are not rendered if the feature gate is disabled. Otherwise the annotation of the shoot takes precedence over
+// the cutoff, which stops rendering them for shoots created at or after it. Both also retire the files of existing
+// nodes. Only if neither decides, i.e. without a cutoff or for shoots of unknown age, the files are rendered, which is
+// reported as retention for nodes which already got them.
+func legacyDNSNTPFilesPolicy(cutoff time.Time, osc *extensionsv1alpha1.OperatingSystemConfig, shoot *shootInfo) (bool, string) {
+	if !features.DefaultFeatureGate.Enabled(features.LegacyDNSNTPFiles) {
+		return false, fmt.Sprintf("feature gate %s is disabled", features.LegacyDNSNTPFiles)
+	}
+
+	if value := shoot.annotation(AnnotationLegacyDNSNTPFiles); value != "" {
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			return enabled, fmt.Sprintf("shoot is annotated with %s=%s", AnnotationLegacyDNSNTPFiles, value)
+		}
+	}
+
+	if !cutoff.IsZero() && shoot != nil && !shoot.creationTimestamp.IsZero() {
+		if !shoot.creationTimestamp.Before(cutoff) {
+			return false, fmt.Sprintf("shoot was created at or after the cutoff %s", cutoff.Format(time.RFC3339))
+		}
+		return true, fmt.Sprintf("shoot was created before the cutoff %s", cutoff.Format(time.RFC3339))
+	}
+
+	if hasLegacyDNSNTPFiles(osc) {
+		return true, "nodes already got the files and keep them until they are replaced"
+	}
+	if cutoff.IsZero() {
+		return true, "no cutoff is configured"
+	}
+
+	return true, "creation of the shoot is unknown"
+}
+
+// hasLegacyDNSNTPFiles returns whether the nodes of the given OperatingSystemConfig already got legacy DNS or NTP files.
+// The gardener-node-agent removes files which are no longer part of the OperatingSystemConfig, which would leave the
+// nodes without the DNS and NTP configuration they depend on.
+func hasLegacyDNSNTPFiles(osc *extensionsv1alpha1.OperatingSystemConfig) bool {
+	if osc.Spec.Purpose != extensionsv1alpha1.OperatingSystemConfigPurposeReconcile {
+		return false
+	}
+
+	return slices.ContainsFunc(osc.Status.ExtensionFiles, func(f extensionsv1alpha1.File) bool {
+		return slices.Contains(legacyDNSNTPFilePaths, f.Path)
+	})
+}
+
+// dependsOnLegacyDNSNTPFiles returns whether legacy DNS or NTP files are rendered for the given network isolation.
+func dependsOnLegacyDNSNTPFiles(networkIsolation *metal.NetworkIsolation) bool {
+	return len(networkIsolation.RegistryMirrors) > 0 && (len(networkIsolation.DNSServers) > 0 || len(networkIsolation.NTPServers) > 0)
+}
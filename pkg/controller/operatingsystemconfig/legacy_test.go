@@ -0,0 +1,115 @@
+package operatingsystemconfig
+
+import (
+	"time"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/utils/test"
+	"github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	"github.com/metal-stack/os-metal-extension/pkg/features"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Legacy DNS and NTP files", func() {
+	var (
+		cutoff = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+		shoot = func(created time.Time, annotation string) *shootInfo {
+			info := &shootInfo{creationTimestamp: created}
+			if annotation != "" {
+				info.annotations = map[string]string{AnnotationLegacyDNSNTPFiles: annotation}
+			}
+			return info
+		}
+	)
+
+	DescribeTable("#legacyDNSNTPFilesPolicy",
+		func(cutoff time.Time, shoot *shootInfo, want bool, wantReason string) {
+			got, reason := legacyDNSNTPFilesPolicy(cutoff, &extensionsv1alpha1.OperatingSystemConfig{}, shoot)
+			Expect(got).To(Equal(want))
+			Expect(reason).To(Equal(wantReason))
+		},
+		Entry("no cutoff", time.Time{}, shoot(cutoff.Add(time.Hour), ""), true, "no cutoff is configured"),
+		Entry("unknown shoot", cutoff, nil, true, "creation of the shoot is unknown"),
+		Entry("shoot created before the cutoff", cutoff, shoot(cutoff.Add(-time.Hour), ""), true, "shoot was created before the cutoff 2025-03-01T00:00:00Z"),
+		Entry("shoot created at the cutoff", cutoff, shoot(cutoff, ""), false, "shoot was created at or after the cutoff 2025-03-01T00:00:00Z"),
+		Entry("shoot created after the cutoff", cutoff, shoot(cutoff.Add(time.Hour), ""), false, "shoot was created at or after the cutoff 2025-03-01T00:00:00Z"),
+		Entry("annotation disables the files before the cutoff", cutoff, shoot(cutoff.Add(-time.Hour), "false"), false, "shoot is annotated with os-metal.extensions.gardener.cloud/legacy-dns-ntp-files=false"),
+		Entry("annotation enables the files after the cutoff", cutoff, shoot(cutoff.Add(time.Hour), "true"), true, "shoot is annotated with os-metal.extensions.gardener.cloud/legacy-dns-ntp-files=true"),
+		Entry("invalid annotation is ignored", cutoff, shoot(cutoff.Add(time.Hour), "maybe"), false, "shoot was created at or after the cutoff 2025-03-01T00:00:00Z"),
+	)
+
+	It("should not render the files if the feature gate is disabled", func() {
+		DeferCleanup(test.WithFeatureGate(features.DefaultFeatureGate, features.LegacyDNSNTPFiles, false))
+
+		got, reason := legacyDNSNTPFilesPolicy(cutoff, &extensionsv1alpha1.OperatingSystemConfig{}, shoot(cutoff.Add(-time.Hour), "true"))
+		Expect(got).To(BeFalse())
+		Expect(reason).To(Equal("feature gate LegacyDNSNTPFiles is disabled"))
+	})
+
+	DescribeTable("#legacyDNSNTPFilesPolicy for nodes which already got the files",
+		func(cutoff time.Time, shoot *shootInfo, want bool, wantReason string) {
+			osc := &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{Purpose: extensionsv1alpha1.OperatingSystemConfigPurposeReconcile},
+				Status: extensionsv1alpha1.OperatingSystemConfigStatus{
+					ExtensionFiles: []extensionsv1alpha1.File{{Path: "/etc/os-metal/hosts"}, {Path: "/etc/resolv.conf"}},
+				},
+			}
+
+			got, reason := legacyDNSNTPFilesPolicy(cutoff, osc, shoot)
+			Expect(got).To(Equal(want))
+			Expect(reason).To(Equal(wantReason))
+		},
+		Entry("no cutoff", time.Time{}, shoot(cutoff.Add(time.Hour), ""), true, "nodes already got the files and keep them until they are replaced"),
+		Entry("unknown shoot", cutoff, nil, true, "nodes already got the files and keep them until they are replaced"),
+		Entry("shoot created before the cutoff", cutoff, shoot(cutoff.Add(-time.Hour), ""), true, "shoot was created before the cutoff 2025-03-01T00:00:00Z"),
+		Entry("existing pool crossing the cutoff", cutoff, shoot(cutoff.Add(time.Hour), ""), false, "shoot was created at or after the cutoff 2025-03-01T00:00:00Z"),
+		Entry("annotation disables the files", time.Time{}, shoot(cutoff.Add(-time.Hour), "false"), false, "shoot is annotated with os-metal.extensions.gardener.cloud/legacy-dns-ntp-files=false"),
+	)
+
+	DescribeTable("#hasLegacyDNSNTPFiles",
+		func(purpose extensionsv1alpha1.OperatingSystemConfigPurpose, path string, want bool) {
+			osc := &extensionsv1alpha1.OperatingSystemConfig{
+				Spec: extensionsv1alpha1.OperatingSystemConfigSpec{Purpose: purpose},
+				Status: extensionsv1alpha1.OperatingSystemConfigStatus{
+					ExtensionFiles: []extensionsv1alpha1.File{{Path: "/etc/os-metal/hosts"}, {Path: path}},
+				},
+			}
+
+			Expect(hasLegacyDNSNTPFiles(osc)).To(Equal(want))
+		},
+		Entry("resolved dns config", extensionsv1alpha1.OperatingSystemConfigPurposeReconcile, "/etc/systemd/resolved.conf.d/dns.conf", true),
+		Entry("resolv.conf", extensionsv1alpha1.OperatingSystemConfigPurposeReconcile, "/etc/resolv.conf", true),
+		Entry("timesyncd config", extensionsv1alpha1.OperatingSystemConfigPurposeReconcile, "/etc/systemd/timesyncd.conf", true),
+		Entry("chrony config", extensionsv1alpha1.OperatingSystemConfigPurposeReconcile, "/etc/chrony.conf", true),
+		Entry("no legacy files", extensionsv1alpha1.OperatingSystemConfigPurposeReconcile, "/etc/os-metal/nftables.conf", false),
+		Entry("provision purpose", extensionsv1alpha1.OperatingSystemConfigPurposeProvision, "/etc/resolv.conf", false),
+	)
+
+	It("should remove the files of nodes which already got them if the feature gate is disabled", func() {
+		DeferCleanup(test.WithFeatureGate(features.DefaultFeatureGate, features.LegacyDNSNTPFiles, false))
+
+		osc := &extensionsv1alpha1.OperatingSystemConfig{
+			Spec: extensionsv1alpha1.OperatingSystemConfigSpec{Purpose: extensionsv1alpha1.OperatingSystemConfigPurposeReconcile},
+			Status: extensionsv1alpha1.OperatingSystemConfigStatus{
+				ExtensionFiles: []extensionsv1alpha1.File{{Path: "/etc/resolv.conf"}},
+			},
+		}
+
+		got, reason := legacyDNSNTPFilesPolicy(cutoff, osc, shoot(cutoff.Add(-time.Hour), ""))
+		Expect(got).To(BeFalse())
+		Expect(reason).To(Equal("feature gate LegacyDNSNTPFiles is disabled"))
+	})
+
+	DescribeTable("#dependsOnLegacyDNSNTPFiles",
+		func(networkIsolation *metal.NetworkIsolation, want bool) {
+			Expect(dependsOnLegacyDNSNTPFiles(networkIsolation)).To(Equal(want))
+		},
+		Entry("no network isolation", &metal.NetworkIsolation{}, false),
+		Entry("no registry mirrors", &metal.NetworkIsolation{DNSServers: []string{"1.1.1.1"}}, false),
+		Entry("no dns and ntp servers", &metal.NetworkIsolation{RegistryMirrors: []metal.RegistryMirror{{Name: "a"}}}, false),
+		Entry("dns servers", &metal.NetworkIsolation{RegistryMirrors: []metal.RegistryMirror{{Name: "a"}}, DNSServers: []string{"1.1.1.1"}}, true),
+		Entry("ntp servers", &metal.NetworkIsolation{RegistryMirrors: []metal.RegistryMirror{{Name: "a"}}, NTPServers: []string{"pool.ntp.org"}}, true),
+	)
+})
@@ -0,0 +1,147 @@
+package operatingsystemconfig
+
+import (
+	"errors"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "os_metal"
+
+	// sourceExtension labels the units and files rendered by the extension.
+	sourceExtension = "extension"
+	// sourceGardener labels the units and files of the OperatingSystemConfig provided by Gardener.
+	sourceGardener = "gardener"
+
+	// decodeFailureStrict is the reason of provider configs with unknown or duplicate fields.
+	decodeFailureStrict = "strict"
+	// decodeFailureUnknownKind is the reason of provider configs of an unknown apiVersion or kind.
+	decodeFailureUnknownKind = "unknown_kind"
+	// decodeFailureMalformed is the reason of provider configs which cannot be decoded at all.
+	decodeFailureMalformed = "malformed"
+
+	// validationFailureProviderConfig is the reason of provider configs failing the validation.
+	validationFailureProviderConfig = "provider_config"
+	// validationFailureUnsafePaths is the reason of extension units and files outside of the allowed paths.
+	validationFailureUnsafePaths = "unsafe_paths"
+	// validationFailureUserDataSize is the reason of userdata exceeding the maximum size.
+	validationFailureUserDataSize = "userdata_size"
+	// validationFailureIgnitionWarnings is the reason of ignition configs with warnings in strict mode.
+	validationFailureIgnitionWarnings = "ignition_warnings"
+)
+
+var (
+	renderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "render_duration_seconds",
+		Help:      "Duration of successfully rendering an OperatingSystemConfig in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"purpose", "os_type"})
+
+	userDataSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "userdata_size_bytes",
+		Help:      "Size of the rendered provision userdata in bytes.",
+		Buckets:   prometheus.ExponentialBuckets(1024, 2, 10),
+	}, []string{"os_type", "ignition_version"})
+
+	renderedUnits = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "rendered_units",
+		Help:      "Number of units of a rendered OperatingSystemConfig, by their source.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 8),
+	}, []string{"purpose", "source"})
+
+	renderedFiles = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "rendered_files",
+		Help:      "Number of files of a rendered OperatingSystemConfig, by their source.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 8),
+	}, []string{"purpose", "source"})
+
+	decodeFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "provider_config_decode_failures_total",
+		Help:      "Number of provider configs which could not be decoded, by reason.",
+	}, []string{"reason"})
+
+	validationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "validation_failures_total",
+		Help:      "Number of OperatingSystemConfigs which failed a validation before or after rendering, by reason.",
+	}, []string{"reason"})
+
+	ignitionWarnings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "ignition_warnings_total",
+		Help:      "Number of warnings of the rendered ignition configs.",
+	}, []string{"os_type", "ignition_version"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		renderDuration,
+		userDataSize,
+		renderedUnits,
+		renderedFiles,
+		decodeFailures,
+		validationFailures,
+		ignitionWarnings,
+	)
+}
+
+// observeRenderedContent records the number of units and files of the given OperatingSystemConfig and of the extension.
+func observeRenderedContent(osc *extensionsv1alpha1.OperatingSystemConfig, extensionUnits []extensionsv1alpha1.Unit, extensionFiles []extensionsv1alpha1.File) {
+	purpose := string(osc.Spec.Purpose)
+
+	renderedUnits.WithLabelValues(purpose, sourceGardener).Observe(float64(len(osc.Spec.Units)))
+	renderedUnits.WithLabelValues(purpose, sourceExtension).Observe(float64(len(extensionUnits)))
+	renderedFiles.WithLabelValues(purpose, sourceGardener).Observe(float64(len(osc.Spec.Files)))
+	renderedFiles.WithLabelValues(purpose, sourceExtension).Observe(float64(len(extensionFiles)))
+}
+
+// failure is an error of a decode or validation failure, which is only counted by countFailure once the reconciliation failed.
+// The validator runs the same checks as the actuator, its rejections must not be counted as failures of the reconciliation.
+type failure struct {
+	counter *prometheus.CounterVec
+	reason  string
+	err     error
+}
+
+func (f *failure) Error() string {
+	return f.err.Error()
+}
+
+func (f *failure) Unwrap() error {
+	return f.err
+}
+
+// newFailure returns the given error as a failure of the given counter and reason.
+func newFailure(counter *prometheus.CounterVec, reason string, err error) error {
+	return &failure{counter: counter, reason: reason, err: err}
+}
+
+// countFailure increments the counter of the given error if it is a failure.
+func countFailure(err error) {
+	var f *failure
+	if errors.As(err, &f) {
+		f.counter.WithLabelValues(f.reason).Inc()
+	}
+}
+
+// decodeFailureReason returns the reason a provider config could not be decoded with decodeProviderConfig.
+func decodeFailureReason(err error) string {
+	switch {
+	case runtime.IsStrictDecodingError(err):
+		return decodeFailureStrict
+	// decodeProviderConfig wraps the error of an unknown kind with the expected kind
+	case runtime.IsNotRegisteredError(errors.Unwrap(err)):
+		return decodeFailureUnknownKind
+	default:
+		return decodeFailureMalformed
+	}
+}
@@ -0,0 +1,163 @@
+package operatingsystemconfig
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/gardener/gardener/pkg/utils/test"
+	"github.com/go-logr/logr"
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig/ignition"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var _ = Describe("Metrics", func() {
+	var (
+		ctx = context.TODO()
+		log = logr.Discard()
+
+		a   *actuator
+		osc *extensionsv1alpha1.OperatingSystemConfig
+
+		scrape = func() string {
+			server := httptest.NewServer(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return string(body)
+		}
+	)
+
+	BeforeEach(func() {
+		for _, vec := range []interface{ Reset() }{renderDuration, userDataSize, renderedUnits, renderedFiles, decodeFailures, validationFailures, ignitionWarnings} {
+			vec.Reset()
+		}
+
+		a = NewActuator(test.FakeManager{
+			Client:        fakeclient.NewClientBuilder().WithScheme(kubernetes.SeedScheme).Build(),
+			EventRecorder: record.NewFakeRecorder(10),
+		}, ActuatorOptions{}).(*actuator)
+
+		osc = &extensionsv1alpha1.OperatingSystemConfig{
+			Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+				DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: "ubuntu"},
+				CRIConfig:   &extensionsv1alpha1.CRIConfig{Name: extensionsv1alpha1.CRINameContainerD},
+				Purpose:     extensionsv1alpha1.OperatingSystemConfigPurposeProvision,
+				Units:       []extensionsv1alpha1.Unit{{Name: "some-unit.service", Content: ptr.To("foo")}},
+				Files:       []extensionsv1alpha1.File{{Path: "/some/file", Content: extensionsv1alpha1.FileContent{Inline: &extensionsv1alpha1.FileContentInline{Data: "bar"}}}},
+			},
+		}
+	})
+
+	It("should expose the render duration and the userdata size of the provision userdata", func() {
+		userData, _, _, err := a.Reconcile(ctx, log, osc)
+		Expect(err).NotTo(HaveOccurred())
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`os_metal_render_duration_seconds_count{os_type="ubuntu",purpose="provision"} 1`))
+		Expect(body).To(ContainSubstring(`os_metal_userdata_size_bytes_count{ignition_version="2.3.0",os_type="ubuntu"} 1`))
+		Expect(body).To(ContainSubstring(`os_metal_userdata_size_bytes_sum{ignition_version="2.3.0",os_type="ubuntu"} ` + strconv.Itoa(len(userData))))
+		Expect(body).To(ContainSubstring(`os_metal_ignition_warnings_total{ignition_version="2.3.0",os_type="ubuntu"} 0`))
+	})
+
+	It("should expose the number of units and files by their source", func() {
+		osc.Spec.Purpose = extensionsv1alpha1.OperatingSystemConfigPurposeReconcile
+
+		_, extensionUnits, extensionFiles, err := a.Reconcile(ctx, log, osc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(extensionUnits).To(HaveLen(1))
+		Expect(extensionFiles).To(HaveLen(3))
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`os_metal_render_duration_seconds_count{os_type="ubuntu",purpose="reconcile"} 1`))
+		Expect(body).To(ContainSubstring(`os_metal_rendered_units_sum{purpose="reconcile",source="gardener"} 1`))
+		Expect(body).To(ContainSubstring(`os_metal_rendered_units_sum{purpose="reconcile",source="extension"} 1`))
+		Expect(body).To(ContainSubstring(`os_metal_rendered_files_sum{purpose="reconcile",source="gardener"} 1`))
+		Expect(body).To(ContainSubstring(`os_metal_rendered_files_sum{purpose="reconcile",source="extension"} 3`))
+		Expect(body).NotTo(ContainSubstring(`os_metal_userdata_size_bytes_count`))
+	})
+
+	It("should expose the transpiler warnings and failures in strict mode", func() {
+		a.opts.Ignition = ignition.Options{Strict: true}
+		osc.Spec.Units = []extensionsv1alpha1.Unit{{Name: "foo.service", Content: ptr.To("[Service]\nExecStart=/bin/foo"), Enable: ptr.To(true)}}
+
+		_, _, _, err := a.Reconcile(ctx, log, osc)
+		Expect(err).To(MatchError(ContainSubstring("strict mode")))
+
+		body := scrape()
+		Expect(body).To(MatchRegexp(`os_metal_ignition_warnings_total{ignition_version="2.3.0",os_type="ubuntu"} [1-9]`))
+		Expect(body).To(ContainSubstring(`os_metal_validation_failures_total{reason="ignition_warnings"} 1`))
+		Expect(body).NotTo(ContainSubstring(`os_metal_render_duration_seconds_count`))
+	})
+
+	It("should expose the userdata size failures", func() {
+		a.opts.MaxUserDataSize = 1
+
+		_, _, _, err := a.Reconcile(ctx, log, osc)
+		Expect(err).To(HaveOccurred())
+
+		Expect(scrape()).To(ContainSubstring(`os_metal_validation_failures_total{reason="userdata_size"} 1`))
+	})
+
+	DescribeTable("should expose the decode failures by reason",
+		func(providerConfig string, reason string) {
+			osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(providerConfig)}
+
+			_, _, _, err := a.Reconcile(ctx, log, osc)
+			Expect(err).To(HaveOccurred())
+
+			Expect(scrape()).To(ContainSubstring(`os_metal_provider_config_decode_failures_total{reason="` + reason + `"} 1`))
+		},
+		Entry("unknown field", `{"apiVersion":"metal.provider.extensions.gardener.cloud/v1alpha1","kind":"ImageProviderConfig","foo":"bar"}`, "strict"),
+		Entry("unknown kind", `{"apiVersion":"metal.provider.extensions.gardener.cloud/v1alpha1","kind":"Foo"}`, "unknown_kind"),
+		Entry("malformed", `{"networkIsolation":"foo"}`, "malformed"),
+	)
+
+	It("should expose the validation failures of the provider config", func() {
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"apiVersion":"metal.provider.extensions.gardener.cloud/v1alpha1","kind":"ImageProviderConfig","networkIsolation":{"dnsServers":["foo"]}}`)}
+
+		_, _, _, err := a.Reconcile(ctx, log, osc)
+		Expect(err).To(HaveOccurred())
+
+		Expect(scrape()).To(ContainSubstring(`os_metal_validation_failures_total{reason="provider_config"} 1`))
+	})
+
+	It("should expose the validation failures of unsafe paths", func() {
+		a.opts.AllowedPaths = []string{"/etc/os-metal"}
+		osc.Spec.Purpose = extensionsv1alpha1.OperatingSystemConfigPurposeReconcile
+
+		_, _, _, err := a.Reconcile(ctx, log, osc)
+		Expect(err).To(MatchError(ContainSubstring("refusing to render unsafe extension files")))
+
+		Expect(scrape()).To(ContainSubstring(`os_metal_validation_failures_total{reason="unsafe_paths"} 1`))
+	})
+
+	It("should not count the rejections of the validator", func() {
+		validator := NewValidator(test.FakeManager{Client: fakeclient.NewClientBuilder().WithScheme(kubernetes.SeedScheme).Build()}, ActuatorOptions{})
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"apiVersion":"metal.provider.extensions.gardener.cloud/v1alpha1","kind":"ImageProviderConfig","foo":"bar"}`)}
+		Expect(validator.Validate(ctx, osc, nil)).NotTo(Succeed())
+
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"apiVersion":"metal.provider.extensions.gardener.cloud/v1alpha1","kind":"ImageProviderConfig","networkIsolation":{"dnsServers":["foo"]}}`)}
+		Expect(validator.Validate(ctx, osc, nil)).NotTo(Succeed())
+
+		body := scrape()
+		Expect(body).NotTo(ContainSubstring(`os_metal_provider_config_decode_failures_total{`))
+		Expect(body).NotTo(ContainSubstring(`os_metal_validation_failures_total{`))
+	})
+})
@@ -0,0 +1,385 @@
+package operatingsystemconfig
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// pinnedHostsPath contains the hosts entries pinning the registry mirrors to their IPs.
+	pinnedHostsPath = "/etc/os-metal/hosts"
+	// pinHostsScriptPath is the path of the script merging the pinned hosts entries into /etc/hosts.
+	pinHostsScriptPath = "/usr/local/bin/os-metal-pin-hosts"
+	// pinHostsUnitName is the name of the unit merging the pinned hosts entries into /etc/hosts.
+	pinHostsUnitName = "os-metal-pin-hosts.service"
+
+	pinHostsScript = `#!/bin/bash
+# Generated by os-extension-metal
+# Replaces the entries managed by os-extension-metal in /etc/hosts with the entries of ` + pinnedHostsPath + `,
+# all other entries of /etc/hosts are preserved. Without entries the managed block is removed.
+set -o errexit
+set -o nounset
+set -o pipefail
+
+begin="# BEGIN os-extension-metal"
+end="# END os-extension-metal"
+
+entries="$(grep -v '^#' ` + pinnedHostsPath + ` || true)"
+if [ -z "$entries" ] && ! grep -q "^${begin}\$" /etc/hosts; then
+  exit 0
+fi
+
+hosts="$(mktemp /etc/hosts.XXXXXX)"
+trap 'rm -f "$hosts"' EXIT
+
+sed "/^${begin}\$/,/^${end}\$/d" /etc/hosts > "$hosts"
+if [ -n "$entries" ]; then
+  {
+    echo "$begin"
+    echo "$entries"
+    echo "$end"
+  } >> "$hosts"
+fi
+
+chmod 0644 "$hosts"
+mv "$hosts" /etc/hosts
+`
+
+	pinHostsUnit = `# Generated by os-extension-metal
+[Unit]
+Description=Pin registry mirror hostnames in /etc/hosts
+Before=containerd.service
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=` + pinHostsScriptPath + `
+
+[Install]
+WantedBy=multi-user.target
+`
+)
+
+// pinnedMirrorHosts returns the units and files pinning the hostnames of the registry mirror endpoints to their IPs,
+// such that the mirrors can be reached before the DNS configuration is active. They are rendered without pinned hosts
+// as well, such that previously pinned entries are removed from /etc/hosts once the mirrors are removed.
+func pinnedMirrorHosts(mirrors []metal.RegistryMirror) ([]extensionsv1alpha1.Unit, []extensionsv1alpha1.File) {
+	hosts := "# Generated by os-extension-metal\n"
+
+	for _, m := range mirrors {
+		u, err := url.Parse(m.Endpoint)
+		if err != nil || m.IP == "" {
+			continue
+		}
+
+		hostname := u.Hostname()
+		if hostname == "" || net.ParseIP(hostname) != nil {
+			continue
+		}
+
+		hosts += fmt.Sprintf("%s %s\n", m.IP, hostname)
+	}
+
+	units := []extensionsv1alpha1.Unit{
+		{
+			Name:      pinHostsUnitName,
+			Command:   ptr.To(extensionsv1alpha1.CommandStart),
+			Enable:    ptr.To(true),
+			Content:   ptr.To(pinHostsUnit),
+			FilePaths: []string{pinHostsScriptPath, pinnedHostsPath},
+		},
+	}
+
+	files := []extensionsv1alpha1.File{
+		{
+			Path:        pinHostsScriptPath,
+			Permissions: ptr.To(int32(0755)),
+			Content: extensionsv1alpha1.FileContent{
+				Inline: &extensionsv1alpha1.FileContentInline{
+					Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+					Data:     pinHostsScript,
+				},
+			},
+		},
+		{
+			Path:        pinnedHostsPath,
+			Permissions: ptr.To(int32(0644)),
+			Content: extensionsv1alpha1.FileContent{
+				Inline: &extensionsv1alpha1.FileContentInline{
+					Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+					Data:     hosts,
+				},
+			},
+		},
+	}
+
+	return units, files
+}
+
+const (
+	// mirrorCAKey is the key of the ca bundle in the secret referenced by the CABundleSecretRef of a registry mirror.
+	mirrorCAKey = "ca.crt"
+	// mirrorClientCertKey is the key of the client certificate in the secret referenced by the ClientCertificateSecretRef of a registry mirror.
+	mirrorClientCertKey = corev1.TLSCertKey
+	// mirrorClientKeyKey is the key of the client key in the secret referenced by the ClientCertificateSecretRef of a registry mirror.
+	mirrorClientKeyKey = corev1.TLSPrivateKeyKey
+)
+
+// mirrorTLS is the resolved TLS configuration of a registry mirror.
+type mirrorTLS struct {
+	caBundle string
+	// clientCertificate is set if the mirror requires a client certificate, whose certificate and key are only resolved
+	// for the purpose provision.
+	clientCertificate bool
+	clientCert        string
+	clientKey         string
+	skipVerify        bool
+}
+
+// resolveMirrorTLS returns the TLS configuration of the given registry mirrors by their endpoint.
+// Referenced secrets are read from the namespace of the given OperatingSystemConfig.
+//
+// Client certificates are only resolved for the purpose provision, whose userdata is stored in a secret. The extension
+// files of the purpose reconcile are stored in the status of the OperatingSystemConfig, which must not contain private keys,
+// hence the nodes keep the client certificate they were provisioned with.
+func (a *actuator) resolveMirrorTLS(ctx context.Context, osc *extensionsv1alpha1.OperatingSystemConfig, mirrors []metal.RegistryMirror) (map[string]mirrorTLS, error) {
+	res := map[string]mirrorTLS{}
+
+	for _, m := range mirrors {
+		if m.TLS == nil {
+			continue
+		}
+		if _, ok := res[m.Endpoint]; ok {
+			continue
+		}
+
+		tls := mirrorTLS{
+			caBundle:   ptr.Deref(m.TLS.CABundle, ""),
+			skipVerify: m.TLS.InsecureSkipVerify,
+		}
+
+		if ref := m.TLS.CABundleSecretRef; ref != nil {
+			data, err := a.secretData(ctx, osc.Namespace, ref.Name, mirrorCAKey)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read ca bundle of registry mirror %q: %w", m.Name, err)
+			}
+
+			if tls.caBundle != "" && !strings.HasSuffix(tls.caBundle, "\n") {
+				tls.caBundle += "\n"
+			}
+			tls.caBundle += string(data)
+		}
+
+		if ref := m.TLS.ClientCertificateSecretRef; ref != nil && osc.Spec.Purpose == extensionsv1alpha1.OperatingSystemConfigPurposeProvision {
+			cert, err := a.secretData(ctx, osc.Namespace, ref.Name, mirrorClientCertKey)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read client certificate of registry mirror %q: %w", m.Name, err)
+			}
+			key, err := a.secretData(ctx, osc.Namespace, ref.Name, mirrorClientKeyKey)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read client certificate of registry mirror %q: %w", m.Name, err)
+			}
+
+			tls.clientCert = string(cert)
+			tls.clientKey = string(key)
+		}
+		tls.clientCertificate = m.TLS.ClientCertificateSecretRef != nil
+
+		res[m.Endpoint] = tls
+	}
+
+	return res, nil
+}
+
+// unresolvedMirrorTLS returns the TLS configuration of the given registry mirrors by their endpoint without reading
+// referenced secrets. The certificates are placeholders, which is sufficient to determine the rendered files.
+func unresolvedMirrorTLS(mirrors []metal.RegistryMirror) map[string]mirrorTLS {
+	res := map[string]mirrorTLS{}
+
+	for _, m := range mirrors {
+		if m.TLS == nil {
+			continue
+		}
+		if _, ok := res[m.Endpoint]; ok {
+			continue
+		}
+
+		tls := mirrorTLS{skipVerify: m.TLS.InsecureSkipVerify}
+		if m.TLS.CABundle != nil || m.TLS.CABundleSecretRef != nil {
+			tls.caBundle = "unresolved"
+		}
+		if m.TLS.ClientCertificateSecretRef != nil {
+			tls.clientCertificate = true
+			tls.clientCert = "unresolved"
+			tls.clientKey = "unresolved"
+		}
+
+		res[m.Endpoint] = tls
+	}
+
+	return res
+}
+
+// secretData returns the data of the given key of a secret.
+func (a *actuator) secretData(ctx context.Context, namespace, name, key string) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := a.client.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("unable to get secret %q: %w", name, err)
+	}
+
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %q does not contain data key %q", name, key)
+	}
+
+	return data, nil
+}
+
+// additionalContainerdMirrors returns a hosts.toml for every upstream registry mirrored by at least one of the given mirrors.
+// An upstream mirrored multiple times gets a host for each mirror, which containerd tries in the order of their
+// endpoints, such that pulls fail over to the next mirror.
+// The certificates of mirrors with a TLS configuration are written next to the hosts.toml of the upstream, client
+// certificates only if they are resolved, otherwise they are only referenced.
+// If the upstream fallback is disabled, the last of the configured mirrors is used as server instead of the upstream
+// registry, such that containerd never tries to pull from the upstream registry. Containerd tries the server after all hosts.
+func additionalContainerdMirrors(registryConfigPath string, mirrors []metal.RegistryMirror, mirrorTLS map[string]mirrorTLS, disableFallback bool) ([]extensionsv1alpha1.File, error) {
+	var (
+		upstreams []string
+		endpoints = map[string][]string{}
+	)
+
+	for _, m := range mirrors {
+		for _, of := range m.MirrorOf {
+			if _, ok := endpoints[of]; !ok {
+				upstreams = append(upstreams, of)
+			}
+			if !slices.Contains(endpoints[of], m.Endpoint) {
+				endpoints[of] = append(endpoints[of], m.Endpoint)
+			}
+		}
+	}
+
+	var files []extensionsv1alpha1.File
+
+	for _, of := range upstreams {
+		host, server := upstreamServer(of)
+		if err := validatePathElement(host); err != nil {
+			return nil, fmt.Errorf("registry mirror upstream %q is invalid: %w", of, err)
+		}
+		var (
+			dir       = path.Join(registryConfigPath, host)
+			hosts     = containerdHosts{Server: server}
+			certFiles []extensionsv1alpha1.File
+		)
+
+		for i, endpoint := range endpoints[of] {
+			h := containerdHost{
+				Capabilities: []string{"pull", "resolve"},
+			}
+
+			if tls, ok := mirrorTLS[endpoint]; ok {
+				prefix := path.Join(dir, mirrorFilePrefix(endpoint))
+
+				if tls.caBundle != "" {
+					h.CA = prefix + "-ca.crt"
+					certFiles = append(certFiles, plainFile(h.CA, 0644, tls.caBundle))
+				}
+				if tls.clientCertificate {
+					h.Client = [][2]string{{prefix + "-client.crt", prefix + "-client.key"}}
+				}
+				if tls.clientCert != "" {
+					certFiles = append(certFiles,
+						plainFile(prefix+"-client.crt", 0644, tls.clientCert),
+						plainFile(prefix+"-client.key", 0600, tls.clientKey),
+					)
+				}
+				h.SkipVerify = tls.skipVerify
+			}
+
+			// without the upstream fallback, the last mirror takes the place of the server
+			if disableFallback && i == len(endpoints[of])-1 {
+				hosts.Server = endpoint
+				hosts.Capabilities, hosts.CA, hosts.Client, hosts.SkipVerify = h.Capabilities, h.CA, h.Client, h.SkipVerify
+				continue
+			}
+
+			if hosts.Hosts == nil {
+				hosts.Hosts = map[string]containerdHost{}
+			}
+			hosts.Hosts[endpoint] = h
+		}
+
+		content, err := hosts.encode()
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, extensionsv1alpha1.File{
+			Path: path.Join(dir, "hosts.toml"),
+			Content: extensionsv1alpha1.FileContent{
+				Inline: &extensionsv1alpha1.FileContentInline{
+					Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+					Data:     content,
+				},
+			},
+		})
+		files = append(files, certFiles...)
+	}
+
+	return files, nil
+}
+
+// upstreamServers maps well-known registries to the server actually serving their registry API.
+var upstreamServers = map[string]string{
+	"docker.io": "https://registry-1.docker.io",
+}
+
+// upstreamServer returns the host of the given upstream registry, which names its directory in the registry config path,
+// and the address of its server. The upstream may carry a scheme and a port, the scheme defaults to https.
+func upstreamServer(upstream string) (host, server string) {
+	scheme, host, found := strings.Cut(upstream, "://")
+	if !found {
+		scheme, host = "https", upstream
+	}
+	host = strings.TrimSuffix(host, "/")
+
+	if s, ok := upstreamServers[host]; ok {
+		return host, s
+	}
+
+	return host, scheme + "://" + host
+}
+
+// mirrorFilePrefix returns the prefix of the certificate files of the mirror with the given endpoint.
+func mirrorFilePrefix(endpoint string) string {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	return strings.NewReplacer(":", "_", "/", "_").Replace(host)
+}
+
+func plainFile(path string, permissions int32, data string) extensionsv1alpha1.File {
+	return extensionsv1alpha1.File{
+		Path:        path,
+		Permissions: ptr.To(permissions),
+		Content: extensionsv1alpha1.FileContent{
+			Inline: &extensionsv1alpha1.FileContentInline{
+				Encoding: string(extensionsv1alpha1.PlainFileCodecID),
+				Data:     data,
+			},
+		},
+	}
+}
@@ -0,0 +1,248 @@
+package operatingsystemconfig
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/os-metal-extension/pkg/apis/metal"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mirror", func() {
+	Describe("#pinnedMirrorHosts", func() {
+		It("should pin the hostnames of all mirrors", func() {
+			units, files := pinnedMirrorHosts([]metal.RegistryMirror{
+				{Name: "a", Endpoint: "https://a.metal-stack.dev", IP: "1.2.3.4"},
+				{Name: "b", Endpoint: "http://b.metal-stack.dev:8080", IP: "1.2.3.5"},
+				{Name: "c", Endpoint: "https://c.metal-stack.dev"},
+				{Name: "d", Endpoint: "https://10.0.0.1", IP: "10.0.0.1"},
+			})
+
+			Expect(units).To(ConsistOf(HaveField("Name", pinHostsUnitName)))
+			Expect(files).To(HaveLen(2))
+			Expect(files[0].Path).To(Equal(pinHostsScriptPath))
+			Expect(files[1].Path).To(Equal(pinnedHostsPath))
+			Expect(files[1].Content.Inline.Data).To(Equal(`# Generated by os-extension-metal
+1.2.3.4 a.metal-stack.dev
+1.2.3.5 b.metal-stack.dev
+`))
+		})
+
+		It("should render the script without pinned hosts", func() {
+			units, files := pinnedMirrorHosts([]metal.RegistryMirror{
+				{Name: "c", Endpoint: "https://c.metal-stack.dev"},
+			})
+
+			Expect(units).To(ConsistOf(HaveField("Name", pinHostsUnitName)))
+			Expect(files).To(HaveLen(2))
+			Expect(files[1].Content.Inline.Data).To(Equal("# Generated by os-extension-metal\n"))
+		})
+
+		It("should replace and remove the pinned hosts in /etc/hosts", func() {
+			bash, err := exec.LookPath("bash")
+			if err != nil {
+				Skip("bash is not installed")
+			}
+
+			var (
+				dir         = GinkgoT().TempDir()
+				etcHosts    = filepath.Join(dir, "etc-hosts")
+				pinnedHosts = filepath.Join(dir, "pinned-hosts")
+				script      = strings.NewReplacer(pinnedHostsPath, pinnedHosts, "/etc/hosts", etcHosts).Replace(pinHostsScript)
+			)
+
+			run := func(hosts string) string {
+				Expect(os.WriteFile(pinnedHosts, []byte(hosts), 0600)).To(Succeed())
+				out, err := exec.Command(bash, "-c", script).CombinedOutput()
+				Expect(err).NotTo(HaveOccurred(), string(out))
+
+				content, err := os.ReadFile(etcHosts)
+				Expect(err).NotTo(HaveOccurred())
+				return string(content)
+			}
+
+			Expect(os.WriteFile(etcHosts, []byte("127.0.0.1 localhost\n"), 0600)).To(Succeed())
+
+			Expect(run("# Generated by os-extension-metal\n1.2.3.4 a.metal-stack.dev\n")).To(Equal(`127.0.0.1 localhost
+# BEGIN os-extension-metal
+1.2.3.4 a.metal-stack.dev
+# END os-extension-metal
+`))
+			Expect(run("# Generated by os-extension-metal\n1.2.3.5 b.metal-stack.dev\n")).To(Equal(`127.0.0.1 localhost
+# BEGIN os-extension-metal
+1.2.3.5 b.metal-stack.dev
+# END os-extension-metal
+`))
+			Expect(run("# Generated by os-extension-metal\n")).To(Equal("127.0.0.1 localhost\n"))
+			Expect(run("# Generated by os-extension-metal\n")).To(Equal("127.0.0.1 localhost\n"))
+		})
+	})
+
+	Describe("#additionalContainerdMirrors", func() {
+		It("should merge the mirrors of an upstream", func() {
+			files, err := additionalContainerdMirrors("/etc/containerd/certs.d", []metal.RegistryMirror{
+				{Name: "a", Endpoint: "https://a.metal-stack.dev", MirrorOf: []string{"ghcr.io", "quay.io"}},
+				{Name: "b", Endpoint: "https://b.metal-stack.dev", MirrorOf: []string{"docker.io", "ghcr.io"}},
+				{Name: "c", Endpoint: "https://a.metal-stack.dev", MirrorOf: []string{"ghcr.io"}},
+			}, nil, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(HaveLen(3))
+
+			Expect(files[0].Path).To(Equal("/etc/containerd/certs.d/ghcr.io/hosts.toml"))
+			Expect(files[0].Content.Inline.Data).To(Equal(`server = "https://ghcr.io"
+
+[host]
+  [host."https://a.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+  [host."https://b.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+`))
+
+			Expect(files[1].Path).To(Equal("/etc/containerd/certs.d/quay.io/hosts.toml"))
+			Expect(files[1].Content.Inline.Data).To(Equal(`server = "https://quay.io"
+
+[host]
+  [host."https://a.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+`))
+
+			Expect(files[2].Path).To(Equal("/etc/containerd/certs.d/docker.io/hosts.toml"))
+			Expect(files[2].Content.Inline.Data).To(Equal(`server = "https://registry-1.docker.io"
+
+[host]
+  [host."https://b.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+`))
+		})
+
+		It("should sort the hosts by their endpoint", func() {
+			files, err := additionalContainerdMirrors("/etc/containerd/certs.d", []metal.RegistryMirror{
+				{Name: "z", Endpoint: "https://z.metal-stack.dev", MirrorOf: []string{"ghcr.io"}},
+				{Name: "a", Endpoint: "https://a.metal-stack.dev", MirrorOf: []string{"ghcr.io"}},
+			}, nil, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Content.Inline.Data).To(Equal(`server = "https://ghcr.io"
+
+[host]
+  [host."https://a.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+  [host."https://z.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+`))
+		})
+
+		It("should reference the certificates of mirrors with a tls configuration", func() {
+			files, err := additionalContainerdMirrors("/etc/containerd/certs.d", []metal.RegistryMirror{
+				{Name: "a", Endpoint: "https://a.metal-stack.dev:8443", MirrorOf: []string{"ghcr.io"}},
+				{Name: "b", Endpoint: "https://b.metal-stack.dev", MirrorOf: []string{"ghcr.io"}},
+			}, map[string]mirrorTLS{
+				"https://a.metal-stack.dev:8443": {caBundle: "ca", clientCertificate: true, clientCert: "cert", clientKey: "key"},
+				"https://b.metal-stack.dev":      {skipVerify: true},
+			}, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(HaveLen(4))
+
+			Expect(files[0].Path).To(Equal("/etc/containerd/certs.d/ghcr.io/hosts.toml"))
+			Expect(files[0].Content.Inline.Data).To(Equal(`server = "https://ghcr.io"
+
+[host]
+  [host."https://a.metal-stack.dev:8443"]
+    capabilities = ["pull", "resolve"]
+    ca = "/etc/containerd/certs.d/ghcr.io/a.metal-stack.dev_8443-ca.crt"
+    client = [["/etc/containerd/certs.d/ghcr.io/a.metal-stack.dev_8443-client.crt", "/etc/containerd/certs.d/ghcr.io/a.metal-stack.dev_8443-client.key"]]
+  [host."https://b.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+    skip_verify = true
+`))
+
+			Expect(files[1:]).To(Equal([]extensionsv1alpha1.File{
+				plainFile("/etc/containerd/certs.d/ghcr.io/a.metal-stack.dev_8443-ca.crt", 0644, "ca"),
+				plainFile("/etc/containerd/certs.d/ghcr.io/a.metal-stack.dev_8443-client.crt", 0644, "cert"),
+				plainFile("/etc/containerd/certs.d/ghcr.io/a.metal-stack.dev_8443-client.key", 0600, "key"),
+			}))
+		})
+
+		It("should only reference client certificates which are not resolved", func() {
+			files, err := additionalContainerdMirrors("/etc/containerd/certs.d", []metal.RegistryMirror{
+				{Name: "a", Endpoint: "https://a.metal-stack.dev", MirrorOf: []string{"ghcr.io"}},
+			}, map[string]mirrorTLS{
+				"https://a.metal-stack.dev": {clientCertificate: true},
+			}, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Content.Inline.Data).To(Equal(`server = "https://ghcr.io"
+
+[host]
+  [host."https://a.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+    client = [["/etc/containerd/certs.d/ghcr.io/a.metal-stack.dev-client.crt", "/etc/containerd/certs.d/ghcr.io/a.metal-stack.dev-client.key"]]
+`))
+		})
+
+		It("should reject upstreams which are not a single path element", func() {
+			_, err := additionalContainerdMirrors("/etc/containerd/certs.d", []metal.RegistryMirror{
+				{Name: "a", Endpoint: "https://a.metal-stack.dev", MirrorOf: []string{"../../systemd/system"}},
+			}, nil, false)
+			Expect(err).To(MatchError(`registry mirror upstream "../../systemd/system" is invalid: "../../systemd/system" is not a valid path element`))
+		})
+
+		It("should use the last mirror as server if the upstream fallback is disabled", func() {
+			files, err := additionalContainerdMirrors("/etc/containerd/certs.d", []metal.RegistryMirror{
+				{Name: "a", Endpoint: "https://a.metal-stack.dev", MirrorOf: []string{"docker.io"}},
+				{Name: "b", Endpoint: "https://b.metal-stack.dev", MirrorOf: []string{"docker.io"}},
+				{Name: "c", Endpoint: "https://c.metal-stack.dev", MirrorOf: []string{"docker.io"}},
+			}, map[string]mirrorTLS{
+				"https://c.metal-stack.dev": {caBundle: "ca", skipVerify: true},
+			}, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(HaveLen(2))
+			Expect(files[0].Path).To(Equal("/etc/containerd/certs.d/docker.io/hosts.toml"))
+			Expect(files[0].Content.Inline.Data).To(Equal(`server = "https://c.metal-stack.dev"
+capabilities = ["pull", "resolve"]
+ca = "/etc/containerd/certs.d/docker.io/c.metal-stack.dev-ca.crt"
+skip_verify = true
+
+[host]
+  [host."https://a.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+  [host."https://b.metal-stack.dev"]
+    capabilities = ["pull", "resolve"]
+`))
+			Expect(files[1]).To(Equal(plainFile("/etc/containerd/certs.d/docker.io/c.metal-stack.dev-ca.crt", 0644, "ca")))
+		})
+
+		It("should only render the server for a single mirror if the upstream fallback is disabled", func() {
+			files, err := additionalContainerdMirrors("/etc/containerd/certs.d", []metal.RegistryMirror{
+				{Name: "a", Endpoint: "https://a.metal-stack.dev", MirrorOf: []string{"ghcr.io"}},
+			}, nil, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Content.Inline.Data).To(Equal(`server = "https://a.metal-stack.dev"
+capabilities = ["pull", "resolve"]
+`))
+		})
+	})
+
+	DescribeTable("#upstreamServer",
+		func(upstream, wantHost, wantServer string) {
+			host, server := upstreamServer(upstream)
+			Expect(host).To(Equal(wantHost))
+			Expect(server).To(Equal(wantServer))
+		},
+		Entry("docker hub", "docker.io", "docker.io", "https://registry-1.docker.io"),
+		Entry("registry", "ghcr.io", "ghcr.io", "https://ghcr.io"),
+		Entry("registry with port", "registry.metal-stack.dev:5000", "registry.metal-stack.dev:5000", "https://registry.metal-stack.dev:5000"),
+		Entry("registry with scheme and port", "http://registry.metal-stack.dev:5000", "registry.metal-stack.dev:5000", "http://registry.metal-stack.dev:5000"),
+	)
+})
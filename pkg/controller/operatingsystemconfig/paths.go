@@ -0,0 +1,94 @@
+package operatingsystemconfig
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+)
+
+// DefaultAllowedPaths returns the paths the files rendered by the extension may be written to by default.
+// A path allows the file itself and, if it is a directory, everything below it.
+func DefaultAllowedPaths() []string {
+	return []string{
+		"/etc/containerd",
+		"/etc/systemd/resolved.conf.d",
+		"/etc/resolv.conf",
+		"/etc/systemd/timesyncd.conf",
+		"/etc/chrony.conf",
+		"/etc/os-metal",
+		pinHostsScriptPath,
+	}
+}
+
+// ValidateAllowedPaths returns an error if one of the given allowed paths is not an absolute and clean path.
+func ValidateAllowedPaths(allowed []string) error {
+	var errs []error
+	for _, p := range allowed {
+		if err := validatePath(p); err != nil {
+			errs = append(errs, fmt.Errorf("allowed path %q is invalid: %w", p, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateExtensionPaths returns an error if one of the given units or files rendered by the extension would be written
+// to a path which is not clean or not below one of the allowed paths. As the units and files are written as root
+// on every node, this prevents provider configs from overwriting arbitrary files.
+func validateExtensionPaths(allowed []string, units []extensionsv1alpha1.Unit, files []extensionsv1alpha1.File) error {
+	var errs []error
+
+	for _, u := range units {
+		if u.Name == "" || u.Name == "." || u.Name == ".." || strings.ContainsAny(u.Name, `/\`) {
+			errs = append(errs, fmt.Errorf("extension unit %q does not have a valid unit name", u.Name))
+		}
+		for _, p := range u.FilePaths {
+			if err := validateAllowedPath(allowed, p); err != nil {
+				errs = append(errs, fmt.Errorf("extension unit %q references an unsafe file %q: %w", u.Name, p, err))
+			}
+		}
+	}
+
+	for _, f := range files {
+		if err := validateAllowedPath(allowed, f.Path); err != nil {
+			errs = append(errs, fmt.Errorf("extension file %q is unsafe: %w", f.Path, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateAllowedPath(allowed []string, p string) error {
+	if err := validatePath(p); err != nil {
+		return err
+	}
+
+	for _, a := range allowed {
+		if p == a || strings.HasPrefix(p, strings.TrimSuffix(a, "/")+"/") {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("path is not below one of the allowed paths %s", strings.Join(allowed, ", "))
+}
+
+// validatePath returns an error if the given path is not absolute or not clean, i.e. contains relative elements.
+func validatePath(p string) error {
+	if !path.IsAbs(p) {
+		return fmt.Errorf("path is not absolute")
+	}
+	if clean := path.Clean(p); clean != p {
+		return fmt.Errorf("path is not clean, it resolves to %q", clean)
+	}
+	return nil
+}
+
+// validatePathElement returns an error if the given name cannot be used as a single element of a path.
+func validatePathElement(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("%q is not a valid path element", name)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package operatingsystemconfig
+
+import (
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Paths", func() {
+	allowed := []string{"/etc/containerd", "/etc/resolv.conf"}
+
+	DescribeTable("#validateExtensionPaths",
+		func(units []extensionsv1alpha1.Unit, files []extensionsv1alpha1.File, wantErr string) {
+			err := validateExtensionPaths(allowed, units, files)
+			if wantErr == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			Expect(err).To(MatchError(wantErr))
+		},
+		Entry("allowed file", nil, []extensionsv1alpha1.File{{Path: "/etc/resolv.conf"}}, ""),
+		Entry("file below an allowed directory", nil, []extensionsv1alpha1.File{{Path: "/etc/containerd/certs.d/ghcr.io/hosts.toml"}}, ""),
+		Entry("file outside of the allowed paths", nil, []extensionsv1alpha1.File{{Path: "/etc/systemd/system/hosts.toml"}},
+			`extension file "/etc/systemd/system/hosts.toml" is unsafe: path is not below one of the allowed paths /etc/containerd, /etc/resolv.conf`),
+		Entry("file sharing a prefix with an allowed path", nil, []extensionsv1alpha1.File{{Path: "/etc/containerd.d/hosts.toml"}},
+			`extension file "/etc/containerd.d/hosts.toml" is unsafe: path is not below one of the allowed paths /etc/containerd, /etc/resolv.conf`),
+		Entry("relative file", nil, []extensionsv1alpha1.File{{Path: "etc/resolv.conf"}},
+			`extension file "etc/resolv.conf" is unsafe: path is not absolute`),
+		Entry("file with traversal", nil, []extensionsv1alpha1.File{{Path: "/etc/containerd/../systemd/system/evil.service"}},
+			`extension file "/etc/containerd/../systemd/system/evil.service" is unsafe: path is not clean, it resolves to "/etc/systemd/system/evil.service"`),
+		Entry("unit with a path as name", []extensionsv1alpha1.Unit{{Name: "../evil.service"}}, nil,
+			`extension unit "../evil.service" does not have a valid unit name`),
+		Entry("unit referencing an unsafe file", []extensionsv1alpha1.Unit{{Name: "a.service", FilePaths: []string{"/usr/bin/a"}}}, nil,
+			`extension unit "a.service" references an unsafe file "/usr/bin/a": path is not below one of the allowed paths /etc/containerd, /etc/resolv.conf`),
+	)
+
+	It("should allow the default extension files", func() {
+		Expect(ValidateAllowedPaths(DefaultAllowedPaths())).To(Succeed())
+	})
+
+	It("should reject invalid allowed paths", func() {
+		Expect(ValidateAllowedPaths([]string{"/etc/", "etc"})).To(MatchError(
+			"allowed path \"/etc/\" is invalid: path is not clean, it resolves to \"/etc\"\n" +
+				"allowed path \"etc\" is invalid: path is not absolute"))
+	})
+})
@@ -0,0 +1,167 @@
+package operatingsystemconfig
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig/ignition"
+)
+
+// Resolver is the way an operating system image resolves names.
+type Resolver string
+
+const (
+	// ResolverUnknown is used for images of which the resolver is not known, they get the configuration of all resolvers.
+	ResolverUnknown Resolver = ""
+	// ResolverResolvConf is used for images reading the nameservers from a plain /etc/resolv.conf.
+	ResolverResolvConf Resolver = "resolv.conf"
+	// ResolverSystemdResolved is used for images resolving names through systemd-resolved.
+	ResolverSystemdResolved Resolver = "systemd-resolved"
+)
+
+// TimeDaemon is the daemon synchronizing the time of an operating system image.
+type TimeDaemon string
+
+const (
+	// TimeDaemonSystemdTimesyncd is used for images synchronizing the time with systemd-timesyncd, which is the default.
+	TimeDaemonSystemdTimesyncd TimeDaemon = "systemd-timesyncd"
+	// TimeDaemonChrony is used for images synchronizing the time with chrony.
+	TimeDaemonChrony TimeDaemon = "chrony"
+)
+
+// Profile declares the capabilities of the images of an operating system type.
+type Profile struct {
+	// Type is the OperatingSystemConfig type the profile applies to.
+	Type string `json:"type"`
+	// Resolver is the way the image resolves names.
+	Resolver Resolver `json:"resolver,omitempty"`
+	// LinkResolvConf is set for images using systemd-resolved whose /etc/resolv.conf does not point to systemd-resolved,
+	// which happens if the image is built in a container where /etc/resolv.conf is mounted.
+	LinkResolvConf bool `json:"linkResolvConf,omitempty"`
+	// TimeDaemon is the daemon synchronizing the time, defaults to systemd-timesyncd.
+	TimeDaemon TimeDaemon `json:"timeDaemon,omitempty"`
+	// Containerd is the containerd layout of the image.
+	Containerd ContainerdLayout `json:"containerd,omitempty"`
+	// IgnitionVersion is the ignition spec version understood by the image, defaults to the configured spec version.
+	IgnitionVersion ignition.SpecVersion `json:"ignitionVersion,omitempty"`
+}
+
+// ContainerdLayout describes where containerd of an image reads its configuration from.
+type ContainerdLayout struct {
+	// RegistryConfigPath is the directory containing the registry host configurations, defaults to /etc/containerd/certs.d.
+	RegistryConfigPath string `json:"registryConfigPath,omitempty"`
+	// ImageConfig is set if the containerd config of the image already reads the registry host configurations,
+	// such that the extension does not need to override it.
+	ImageConfig bool `json:"imageConfig,omitempty"`
+}
+
+// Validate returns an error if the profile is invalid.
+func (p Profile) Validate() error {
+	if p.Type == "" {
+		return fmt.Errorf("profile type must not be empty")
+	}
+
+	switch p.Resolver {
+	case ResolverUnknown, ResolverResolvConf, ResolverSystemdResolved:
+	default:
+		return fmt.Errorf("profile %q has an unsupported resolver %q", p.Type, p.Resolver)
+	}
+
+	switch p.TimeDaemon {
+	case "", TimeDaemonSystemdTimesyncd, TimeDaemonChrony:
+	default:
+		return fmt.Errorf("profile %q has an unsupported time daemon %q", p.Type, p.TimeDaemon)
+	}
+
+	if p.Containerd.RegistryConfigPath != "" {
+		if err := validatePath(p.Containerd.RegistryConfigPath); err != nil {
+			return fmt.Errorf("profile %q has an invalid containerd registry config path %q: %w", p.Type, p.Containerd.RegistryConfigPath, err)
+		}
+	}
+
+	if p.IgnitionVersion != "" {
+		if err := ignition.ValidateSpecVersion(p.IgnitionVersion); err != nil {
+			return fmt.Errorf("profile %q is invalid: %w", p.Type, err)
+		}
+	}
+
+	return nil
+}
+
+func (p Profile) timeDaemon() TimeDaemon {
+	if p.TimeDaemon == "" {
+		return TimeDaemonSystemdTimesyncd
+	}
+	return p.TimeDaemon
+}
+
+func (p Profile) containerdRegistryConfigPath() string {
+	if p.Containerd.RegistryConfigPath == "" {
+		return ignition.DefaultRegistryConfigPath
+	}
+	return p.Containerd.RegistryConfigPath
+}
+
+// DefaultProfiles returns the profiles of the metal-images supported out of the box.
+func DefaultProfiles() []Profile {
+	return []Profile{
+		{Type: "ubuntu", Resolver: ResolverSystemdResolved},
+		{Type: "debian", Resolver: ResolverSystemdResolved, LinkResolvConf: true},
+		{Type: "nvidia", Resolver: ResolverSystemdResolved, LinkResolvConf: true},
+	}
+}
+
+// Registry contains the profiles of all operating system types handled by the extension.
+type Registry struct {
+	profiles map[string]Profile
+}
+
+// NewRegistry creates a new Registry containing the given profiles.
+func NewRegistry(profiles ...Profile) (*Registry, error) {
+	r := &Registry{profiles: map[string]Profile{}}
+
+	for _, p := range profiles {
+		if err := r.Register(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// DefaultRegistry creates a new Registry containing the DefaultProfiles.
+func DefaultRegistry() *Registry {
+	r, err := NewRegistry(DefaultProfiles()...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Register adds the given profile to the registry, an existing profile of the same type is replaced.
+func (r *Registry) Register(p Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	r.profiles[p.Type] = p
+	return nil
+}
+
+// Get returns the profile of the given operating system type.
+func (r *Registry) Get(osType string) (Profile, bool) {
+	p, ok := r.profiles[osType]
+	return p, ok
+}
+
+// Types returns the sorted operating system types of all registered profiles.
+func (r *Registry) Types() []string {
+	var types []string
+	for t := range r.profiles {
+		types = append(types, t)
+	}
+
+	slices.Sort(types)
+
+	return types
+}
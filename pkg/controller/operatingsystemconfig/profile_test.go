@@ -0,0 +1,72 @@
+package operatingsystemconfig_test
+
+import (
+	. "github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Profile", func() {
+	Describe("#Validate", func() {
+		DescribeTable("validates profiles",
+			func(p Profile, wantErr string) {
+				err := p.Validate()
+				if wantErr == "" {
+					Expect(err).NotTo(HaveOccurred())
+					return
+				}
+				Expect(err).To(MatchError(ContainSubstring(wantErr)))
+			},
+			Entry("minimal profile", Profile{Type: "almalinux"}, ""),
+			Entry("full profile", Profile{
+				Type:            "almalinux",
+				Resolver:        ResolverResolvConf,
+				TimeDaemon:      TimeDaemonChrony,
+				Containerd:      ContainerdLayout{RegistryConfigPath: "/etc/containerd/certs.d", ImageConfig: true},
+				IgnitionVersion: "3.4.0",
+			}, ""),
+			Entry("missing type", Profile{}, "profile type must not be empty"),
+			Entry("unsupported resolver", Profile{Type: "almalinux", Resolver: "dnsmasq"}, `unsupported resolver "dnsmasq"`),
+			Entry("unsupported time daemon", Profile{Type: "almalinux", TimeDaemon: "ntpd"}, `unsupported time daemon "ntpd"`),
+			Entry("relative registry config path", Profile{Type: "almalinux", Containerd: ContainerdLayout{RegistryConfigPath: "etc/containerd/certs.d"}}, `invalid containerd registry config path "etc/containerd/certs.d": path is not absolute`),
+			Entry("unclean registry config path", Profile{Type: "almalinux", Containerd: ContainerdLayout{RegistryConfigPath: "/etc/containerd/../systemd"}}, `invalid containerd registry config path "/etc/containerd/../systemd": path is not clean`),
+			Entry("unsupported ignition version", Profile{Type: "almalinux", IgnitionVersion: "1.0.0"}, `unsupported ignition spec version "1.0.0"`),
+		)
+	})
+
+	Describe("Registry", func() {
+		It("should contain the default profiles", func() {
+			Expect(DefaultRegistry().Types()).To(Equal([]string{"debian", "nvidia", "ubuntu"}))
+		})
+
+		It("should register additional profiles", func() {
+			r := DefaultRegistry()
+			Expect(r.Register(Profile{Type: "almalinux", Resolver: ResolverResolvConf})).To(Succeed())
+
+			Expect(r.Types()).To(Equal([]string{"almalinux", "debian", "nvidia", "ubuntu"}))
+
+			p, ok := r.Get("almalinux")
+			Expect(ok).To(BeTrue())
+			Expect(p.Resolver).To(Equal(ResolverResolvConf))
+		})
+
+		It("should replace an existing profile", func() {
+			r := DefaultRegistry()
+			Expect(r.Register(Profile{Type: "ubuntu", Resolver: ResolverResolvConf})).To(Succeed())
+
+			p, ok := r.Get("ubuntu")
+			Expect(ok).To(BeTrue())
+			Expect(p.Resolver).To(Equal(ResolverResolvConf))
+		})
+
+		It("should reject invalid profiles", func() {
+			_, err := NewRegistry(Profile{Type: "almalinux", Resolver: "dnsmasq"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should not find unknown types", func() {
+			_, ok := DefaultRegistry().Get("almalinux")
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
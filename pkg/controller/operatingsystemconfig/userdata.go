@@ -0,0 +1,99 @@
+package operatingsystemconfig
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxUserDataContributors is the amount of files and units listed when the userdata exceeds its maximum size.
+const maxUserDataContributors = 5
+
+// userDataContributor is a file or unit contributing to the size of the userdata.
+type userDataContributor struct {
+	kind string
+	name string
+	size int
+}
+
+func (c userDataContributor) String() string {
+	return fmt.Sprintf("%s %q (%d bytes)", c.kind, c.name, c.size)
+}
+
+// validateUserDataSize returns an error if the userdata exceeds the given maximum size, a maximum of zero disables the check.
+// The error lists the files and units of the userdata contributing most to its size.
+func validateUserDataSize(userData []byte, maxSize int) error {
+	if maxSize <= 0 || len(userData) <= maxSize {
+		return nil
+	}
+
+	contributors, err := userDataContributors(userData)
+	if err != nil {
+		return fmt.Errorf("userdata size of %d bytes exceeds the maximum of %d bytes, unable to determine the largest contributors: %w", len(userData), maxSize, err)
+	}
+	if len(contributors) > maxUserDataContributors {
+		contributors = contributors[:maxUserDataContributors]
+	}
+
+	var largest []string
+	for _, c := range contributors {
+		largest = append(largest, c.String())
+	}
+
+	return fmt.Errorf("userdata size of %d bytes exceeds the maximum of %d bytes, largest contributors are: %s", len(userData), maxSize, strings.Join(largest, ", "))
+}
+
+// renderedUserData contains the parts of an ignition config contributing to its size, which are the same for all
+// supported ignition versions. The contents are kept as they are rendered, i.e. encoded and compressed.
+type renderedUserData struct {
+	Storage struct {
+		Files []struct {
+			Path     string `json:"path"`
+			Contents struct {
+				Source json.RawMessage `json:"source"`
+			} `json:"contents"`
+		} `json:"files"`
+	} `json:"storage"`
+	Systemd struct {
+		Units []struct {
+			Name     string          `json:"name"`
+			Contents json.RawMessage `json:"contents"`
+			Dropins  []struct {
+				Contents json.RawMessage `json:"contents"`
+			} `json:"dropins"`
+		} `json:"units"`
+	} `json:"systemd"`
+}
+
+// userDataContributors returns the files and units of the given rendered userdata ordered by their size in descending order.
+// The sizes are measured in the userdata, hence they include the encoding and compression of the contents as well as the
+// files and units the OperatingSystemConfig was expanded to, e.g. for files from container images.
+func userDataContributors(userData []byte) ([]userDataContributor, error) {
+	rendered := &renderedUserData{}
+	if err := json.Unmarshal(userData, rendered); err != nil {
+		return nil, err
+	}
+
+	var contributors []userDataContributor
+
+	for _, f := range rendered.Storage.Files {
+		contributors = append(contributors, userDataContributor{kind: "file", name: f.Path, size: len(f.Contents.Source)})
+	}
+
+	for _, u := range rendered.Systemd.Units {
+		size := len(u.Contents)
+		for _, d := range u.Dropins {
+			size += len(d.Contents)
+		}
+
+		contributors = append(contributors, userDataContributor{kind: "unit", name: u.Name, size: size})
+	}
+
+	slices.SortStableFunc(contributors, func(a, b userDataContributor) int {
+		return cmp.Compare(b.size, a.size)
+	})
+
+	return contributors, nil
+}
@@ -0,0 +1,62 @@
+package operatingsystemconfig
+
+import (
+	"context"
+	"fmt"
+
+	extensionswebhook "github.com/gardener/gardener/extensions/pkg/webhook"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/go-logr/logr"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+type validator struct {
+	actuator *actuator
+	log      logr.Logger
+}
+
+// NewValidator returns a validator for OperatingSystemConfigs, which runs the checks of the actuator that do not require
+// the referenced secrets: the decoding and validation of the provider config and the path safety of the rendered files.
+// OperatingSystemConfigs of types without a profile are not validated, as they are not reconciled by the actuator.
+func NewValidator(mgr manager.Manager, opts ActuatorOptions) extensionswebhook.Validator {
+	return &validator{
+		actuator: newActuator(mgr.GetClient(), opts),
+		log:      logf.Log.WithName("os-metal-validator"),
+	}
+}
+
+// Validate validates the given OperatingSystemConfig.
+func (v *validator) Validate(ctx context.Context, newObj, oldObj client.Object) error {
+	osc, ok := newObj.(*extensionsv1alpha1.OperatingSystemConfig)
+	if !ok {
+		return fmt.Errorf("wrong object type %T", newObj)
+	}
+
+	if osc.DeletionTimestamp != nil {
+		return nil
+	}
+	if _, ok := v.actuator.opts.Profiles.Get(osc.Spec.Type); !ok {
+		return nil
+	}
+
+	// updates not touching the spec must not be blocked, e.g. removing the finalizers or setting annotations
+	if oldOSC, ok := oldObj.(*extensionsv1alpha1.OperatingSystemConfig); ok && apiequality.Semantic.DeepEqual(oldOSC.Spec, osc.Spec) {
+		return nil
+	}
+
+	networkIsolation, profile, err := v.actuator.prepare(v.log, osc)
+	if err != nil {
+		return err
+	}
+
+	shoot, err := v.actuator.getShootInfo(ctx, osc)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = v.actuator.extensions(osc, profile, shoot, networkIsolation, unresolvedMirrorTLS(networkIsolation.RegistryMirrors))
+	return err
+}
@@ -0,0 +1,125 @@
+package operatingsystemconfig_test
+
+import (
+	"context"
+
+	extensionswebhook "github.com/gardener/gardener/extensions/pkg/webhook"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/gardener/gardener/pkg/utils/test"
+	metalv1alpha1 "github.com/metal-stack/os-metal-extension/pkg/apis/metal/v1alpha1"
+	. "github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Validator", func() {
+	var (
+		ctx = context.TODO()
+
+		validator extensionswebhook.Validator
+		osc       *extensionsv1alpha1.OperatingSystemConfig
+	)
+
+	BeforeEach(func() {
+		validator = NewValidator(test.FakeManager{Client: fakeclient.NewClientBuilder().WithScheme(kubernetes.SeedScheme).Build()}, ActuatorOptions{})
+
+		osc = &extensionsv1alpha1.OperatingSystemConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "osc", Namespace: "shoot--project--name"},
+			Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+				DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: "debian"},
+				CRIConfig:   &extensionsv1alpha1.CRIConfig{Name: "containerd"},
+				Purpose:     extensionsv1alpha1.OperatingSystemConfigPurposeProvision,
+			},
+		}
+	})
+
+	withNetworkIsolation := func(osc *extensionsv1alpha1.OperatingSystemConfig, ni *metalv1alpha1.NetworkIsolation) *extensionsv1alpha1.OperatingSystemConfig {
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: mustMarshal(&metalv1alpha1.ImageProviderConfig{NetworkIsolation: ni})}
+		return osc
+	}
+
+	It("should accept a valid network isolation", func() {
+		withNetworkIsolation(osc, &metalv1alpha1.NetworkIsolation{
+			AllowedNetworks: metalv1alpha1.AllowedNetworks{Egress: []string{"100.0.0.0/24"}},
+			DNSServers:      []string{"1.1.1.1"},
+			RegistryMirrors: []metalv1alpha1.RegistryMirror{
+				{
+					Name:     "metal-stack registry",
+					Endpoint: "https://r.metal-stack.dev",
+					MirrorOf: []string{"ghcr.io"},
+					TLS: &metalv1alpha1.RegistryMirrorTLS{
+						CABundleSecretRef: &corev1.LocalObjectReference{Name: "does-not-exist"},
+					},
+				},
+			},
+		})
+
+		Expect(validator.Validate(ctx, osc, nil)).To(Succeed())
+	})
+
+	It("should reject an invalid network isolation", func() {
+		withNetworkIsolation(osc, &metalv1alpha1.NetworkIsolation{
+			DNSServers: []string{"1.1.1.1,1.0.0.1"},
+		})
+
+		Expect(validator.Validate(ctx, osc, nil)).To(MatchError(`invalid provider config: providerConfig.networkIsolation.dnsServers[0]: Invalid value: "1.1.1.1,1.0.0.1": must be an IPv4 or IPv6 address`))
+	})
+
+	It("should reject provider configs with unknown fields", func() {
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"networkIsolation":{"dnsServer":["1.1.1.1"]}}`)}
+
+		Expect(validator.Validate(ctx, osc, nil)).To(MatchError(And(HavePrefix("unable to decode providerConfig: "), ContainSubstring(`unknown field "networkIsolation.dnsServer"`))))
+	})
+
+	It("should accept provider configs with unknown fields with lenient decoding", func() {
+		validator = NewValidator(test.FakeManager{Client: fakeclient.NewClientBuilder().WithScheme(kubernetes.SeedScheme).Build()}, ActuatorOptions{LenientDecoding: true})
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"networkIsolation":{"dnsServer":["1.1.1.1"]}}`)}
+
+		Expect(validator.Validate(ctx, osc, nil)).To(Succeed())
+	})
+
+	It("should reject extension files outside of the allowed paths", func() {
+		validator = NewValidator(test.FakeManager{Client: fakeclient.NewClientBuilder().WithScheme(kubernetes.SeedScheme).Build()}, ActuatorOptions{AllowedPaths: []string{"/etc/os-metal"}})
+		withNetworkIsolation(osc, &metalv1alpha1.NetworkIsolation{
+			RegistryMirrors: []metalv1alpha1.RegistryMirror{
+				{Name: "metal-stack registry", Endpoint: "https://r.metal-stack.dev", MirrorOf: []string{"ghcr.io"}},
+			},
+		})
+
+		Expect(validator.Validate(ctx, osc, nil)).To(MatchError(ContainSubstring(`extension file "/etc/containerd/certs.d/ghcr.io/hosts.toml" is unsafe`)))
+	})
+
+	It("should not validate operating systems not handled by the extension", func() {
+		osc.Spec.Type = "almalinux"
+		withNetworkIsolation(osc, &metalv1alpha1.NetworkIsolation{
+			DNSServers: []string{"1.1.1.1,1.0.0.1"},
+		})
+
+		Expect(validator.Validate(ctx, osc, nil)).To(Succeed())
+	})
+
+	It("should not block updates without changes to the spec", func() {
+		withNetworkIsolation(osc, &metalv1alpha1.NetworkIsolation{
+			DNSServers: []string{"1.1.1.1,1.0.0.1"},
+		})
+		old := osc.DeepCopy()
+		osc.Finalizers = nil
+
+		Expect(validator.Validate(ctx, osc, old)).To(Succeed())
+		Expect(validator.Validate(ctx, osc, nil)).NotTo(Succeed())
+	})
+
+	It("should not block deletions", func() {
+		withNetworkIsolation(osc, &metalv1alpha1.NetworkIsolation{
+			DNSServers: []string{"1.1.1.1,1.0.0.1"},
+		})
+		osc.DeletionTimestamp = &metav1.Time{}
+
+		Expect(validator.Validate(ctx, osc, nil)).To(Succeed())
+	})
+})
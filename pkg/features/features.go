@@ -0,0 +1,49 @@
+package features
+
+import (
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/component-base/featuregate"
+)
+
+const (
+	// Every feature gate should add a constant here following this template:
+	//
+	// // MyFeature enables Foo.
+	// // alpha: v0.X
+	// MyFeature featuregate.Feature = "MyFeature"
+
+	// ContainerdConfigOverride renders /etc/containerd/config.toml for images which do not ship a containerd config
+	// reading the registry configuration from the config path of the profile.
+	// beta: enabled by default
+	ContainerdConfigOverride featuregate.Feature = "ContainerdConfigOverride"
+
+	// LegacyDNSNTPFiles renders the DNS and NTP configuration of isolated clusters onto the nodes, which is only required
+	// for worker machines created without DNS and NTP configuration through metal-stack.
+	// beta: enabled by default
+	LegacyDNSNTPFiles featuregate.Feature = "LegacyDNSNTPFiles"
+
+	// IgnitionSortedOutput renders the units of the ignition config sorted by name and its files sorted by path,
+	// such that the userdata does not change if Gardener reorders the units and files of an OperatingSystemConfig.
+	// alpha: disabled by default
+	IgnitionSortedOutput featuregate.Feature = "IgnitionSortedOutput"
+
+	// ContainerdConfigEncoder renders /etc/containerd/config.toml with the nested tables of the TOML encoder instead of
+	// the layout of previous versions. Changing the file restarts containerd on all nodes still getting it.
+	// alpha: disabled by default
+	ContainerdConfigEncoder featuregate.Feature = "ContainerdConfigEncoder"
+)
+
+// DefaultFeatureGate is the feature gate of the extension, all features of AllFeatureGates are registered.
+var DefaultFeatureGate = featuregate.NewFeatureGate()
+
+// AllFeatureGates is the list of all feature gates of the extension.
+var AllFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	ContainerdConfigOverride: {Default: true, PreRelease: featuregate.Beta},
+	LegacyDNSNTPFiles:        {Default: true, PreRelease: featuregate.Beta},
+	IgnitionSortedOutput:     {Default: false, PreRelease: featuregate.Alpha},
+	ContainerdConfigEncoder:  {Default: false, PreRelease: featuregate.Alpha},
+}
+
+func init() {
+	utilruntime.Must(DefaultFeatureGate.Add(AllFeatureGates))
+}
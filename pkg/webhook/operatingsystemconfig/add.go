@@ -0,0 +1,51 @@
+package operatingsystemconfig
+
+import (
+	extensionswebhook "github.com/gardener/gardener/extensions/pkg/webhook"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// WebhookName is the name of the webhook validating OperatingSystemConfigs.
+	WebhookName = "os-metal-validator"
+	// WebhookPath is the path the webhook validating OperatingSystemConfigs is served at.
+	WebhookPath = "/webhooks/validate-operatingsystemconfig"
+)
+
+var logger = log.Log.WithName("os-metal-validator-webhook")
+
+// AddToManagerWithOptions creates a webhook validating OperatingSystemConfigs with the given actuator options
+// and adds it to the manager.
+func AddToManagerWithOptions(mgr manager.Manager, opts operatingsystemconfig.ActuatorOptions) (*extensionswebhook.Webhook, error) {
+	logger.Info("Adding webhook to manager")
+
+	wh, err := extensionswebhook.New(mgr, extensionswebhook.Args{
+		Provider: "os-metal",
+		Name:     WebhookName,
+		Path:     WebhookPath,
+		Target:   extensionswebhook.TargetSeed,
+		Validators: map[extensionswebhook.Validator][]extensionswebhook.Type{
+			operatingsystemconfig.NewValidator(mgr, opts): {{Obj: &extensionsv1alpha1.OperatingSystemConfig{}}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the webhook matches the OperatingSystemConfigs of all types, because they carry no label of their type,
+	// so an unavailable extension must not block writing them, invalid configs still fail the reconciliation
+	wh.FailurePolicy = ptr.To(admissionregistrationv1.Ignore)
+
+	return wh, nil
+}
+
+// AddToManager creates a webhook validating OperatingSystemConfigs with the actuator options of the controller
+// and adds it to the manager.
+func AddToManager(mgr manager.Manager) (*extensionswebhook.Webhook, error) {
+	return AddToManagerWithOptions(mgr, operatingsystemconfig.DefaultAddOptions.Actuator)
+}
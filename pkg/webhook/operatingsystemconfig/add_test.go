@@ -0,0 +1,42 @@
+package operatingsystemconfig_test
+
+import (
+	extensionswebhook "github.com/gardener/gardener/extensions/pkg/webhook"
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/gardener/gardener/pkg/utils/test"
+	"github.com/metal-stack/os-metal-extension/pkg/controller/operatingsystemconfig"
+	. "github.com/metal-stack/os-metal-extension/pkg/webhook/operatingsystemconfig"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Add", func() {
+	Describe("#AddToManagerWithOptions", func() {
+		It("should not block OperatingSystemConfigs if the extension is unavailable", func() {
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{extensionsv1alpha1.SchemeGroupVersion})
+			mapper.Add(extensionsv1alpha1.SchemeGroupVersion.WithKind("OperatingSystemConfig"), meta.RESTScopeNamespace)
+			c := fakeclient.NewClientBuilder().WithScheme(kubernetes.SeedScheme).WithRESTMapper(mapper).Build()
+
+			wh, err := AddToManagerWithOptions(test.FakeManager{Client: c, Scheme: kubernetes.SeedScheme}, operatingsystemconfig.ActuatorOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			seedConfigs, shootConfigs, err := extensionswebhook.BuildWebhookConfigs([]*extensionswebhook.Webhook{wh}, c, "extension-os-metal", "os-metal", 443, extensionswebhook.ModeService, "", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(shootConfigs.ValidatingWebhookConfig).To(BeNil())
+			Expect(seedConfigs.MutatingWebhookConfig).To(BeNil())
+			Expect(seedConfigs.ValidatingWebhookConfig).NotTo(BeNil())
+
+			webhooks := seedConfigs.ValidatingWebhookConfig.Webhooks
+			Expect(webhooks).To(HaveLen(1))
+			Expect(webhooks[0].FailurePolicy).To(HaveValue(Equal(admissionregistrationv1.Ignore)))
+			Expect(webhooks[0].NamespaceSelector).To(BeNil())
+			Expect(webhooks[0].ObjectSelector).To(BeNil())
+			Expect(webhooks[0].Rules).To(ConsistOf(HaveField("Rule.Resources", ConsistOf("operatingsystemconfigs"))))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package operatingsystemconfig_test
+
+import (
+	"encoding/json"
+
+	extensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	metalv1alpha1 "github.com/metal-stack/os-metal-extension/pkg/apis/metal/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("OperatingSystemConfig webhook tests", func() {
+	var osc *extensionsv1alpha1.OperatingSystemConfig
+
+	BeforeEach(func() {
+		osc = &extensionsv1alpha1.OperatingSystemConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "osc-",
+				Namespace:    testNamespace.Name,
+			},
+			Spec: extensionsv1alpha1.OperatingSystemConfigSpec{
+				DefaultSpec: extensionsv1alpha1.DefaultSpec{Type: "debian"},
+				CRIConfig:   &extensionsv1alpha1.CRIConfig{Name: extensionsv1alpha1.CRINameContainerD},
+				Purpose:     extensionsv1alpha1.OperatingSystemConfigPurposeProvision,
+			},
+		}
+
+		DeferCleanup(func() {
+			By("Delete OperatingSystemConfig")
+			Expect(client.IgnoreNotFound(testClient.Delete(ctx, osc))).To(Succeed())
+		})
+	})
+
+	withNetworkIsolation := func(ni *metalv1alpha1.NetworkIsolation) {
+		raw, err := json.Marshal(&metalv1alpha1.ImageProviderConfig{NetworkIsolation: ni})
+		Expect(err).NotTo(HaveOccurred())
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: raw}
+	}
+
+	It("should admit a valid network isolation", func() {
+		withNetworkIsolation(&metalv1alpha1.NetworkIsolation{
+			AllowedNetworks: metalv1alpha1.AllowedNetworks{Egress: []string{"100.0.0.0/24"}},
+			DNSServers:      []string{"1.1.1.1"},
+		})
+
+		Eventually(func() error {
+			return testClient.Create(ctx, osc)
+		}).Should(Succeed())
+	})
+
+	It("should deny an invalid network isolation", func() {
+		withNetworkIsolation(&metalv1alpha1.NetworkIsolation{
+			DNSServers: []string{"1.1.1.1,1.0.0.1"},
+		})
+
+		Eventually(func() error {
+			return testClient.Create(ctx, osc.DeepCopy())
+		}).Should(MatchError(ContainSubstring(`providerConfig.networkIsolation.dnsServers[0]: Invalid value: "1.1.1.1,1.0.0.1": must be an IPv4 or IPv6 address`)))
+	})
+
+	It("should deny unsafe extension files", func() {
+		withNetworkIsolation(&metalv1alpha1.NetworkIsolation{
+			RegistryMirrors: []metalv1alpha1.RegistryMirror{
+				{Name: "evil", Endpoint: "https://r.metal-stack.dev", MirrorOf: []string{"../../systemd/system"}},
+			},
+		})
+
+		Eventually(func() error {
+			return testClient.Create(ctx, osc.DeepCopy())
+		}).Should(MatchError(ContainSubstring(`providerConfig.networkIsolation.registryMirrors[0].mirrorOf[0]`)))
+	})
+
+	It("should deny updates to an invalid provider config", func() {
+		withNetworkIsolation(&metalv1alpha1.NetworkIsolation{
+			DNSServers: []string{"1.1.1.1"},
+		})
+
+		Eventually(func() error {
+			return testClient.Create(ctx, osc)
+		}).Should(Succeed())
+
+		patch := client.MergeFrom(osc.DeepCopy())
+		osc.Spec.ProviderConfig = &runtime.RawExtension{Raw: []byte(`{"networkIsolation":{"dnsServer":["1.1.1.1"]}}`)}
+		Expect(testClient.Patch(ctx, osc, patch)).To(MatchError(ContainSubstring(`unknown field "networkIsolation.dnsServer"`)))
+	})
+
+	It("should not validate operating systems not handled by the extension", func() {
+		osc.Spec.Type = "almalinux"
+		withNetworkIsolation(&metalv1alpha1.NetworkIsolation{
+			DNSServers: []string{"1.1.1.1,1.0.0.1"},
+		})
+
+		Eventually(func() error {
+			return testClient.Create(ctx, osc)
+		}).Should(Succeed())
+	})
+})